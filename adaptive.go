@@ -0,0 +1,102 @@
+package liblog
+
+import "sync/atomic"
+
+// adaptiveQueue holds messages between log calls and the worker in a
+// slice that grows with the backlog and shrinks once it is drained.
+type adaptiveQueue struct {
+	// accessed atomically
+	depth int64
+	min   int64
+	max   int64
+
+	intake chan LogMsg
+}
+
+// SetAdaptiveQueue puts a queue between log calls and the worker that
+// grows as messages pile up, to at most max messages, and goes back to
+// room for min messages once the worker has caught up. When max messages
+// are waiting, new ones are dropped and reported like with LOG_QUEUE_LEN.
+// Calling it again changes the bounds. It has no effect on synchronous
+// loggers.
+func (logger *Logger) SetAdaptiveQueue(min, max int) {
+	if min < 1 {
+		min = 1
+	}
+	if max < min {
+		max = min
+	}
+	logger.closeMu.Lock()
+	defer logger.closeMu.Unlock()
+	if logger.synchronous || logger.closed {
+		return
+	}
+	if q := logger.adaptive; q != nil {
+		atomic.StoreInt64(&q.min, int64(min))
+		atomic.StoreInt64(&q.max, int64(max))
+		return
+	}
+	q := &adaptiveQueue{min: int64(min), max: int64(max), intake: make(chan LogMsg)}
+	logger.adaptive = q
+	go logger.pump(q)
+}
+
+// QueueDepth returns the number of messages waiting to be written.
+func (logger *Logger) QueueDepth() int {
+	depth := len(logger.output)
+	if q := logger.adaptiveQueue(); q != nil {
+		depth += int(atomic.LoadInt64(&q.depth))
+	}
+	return depth
+}
+
+func (logger *Logger) adaptiveQueue() *adaptiveQueue {
+	logger.closeMu.RLock()
+	defer logger.closeMu.RUnlock()
+	return logger.adaptive
+}
+
+// pump moves messages from the intake to the worker, keeping the ones the
+// worker is not ready for in buf[head:].
+func (logger *Logger) pump(q *adaptiveQueue) {
+	buf := make([]LogMsg, 0, q.min)
+	head := 0
+	for {
+		var out chan LogMsg
+		var next LogMsg
+		if head < len(buf) {
+			out, next = logger.output, buf[head]
+		}
+		select {
+		case msg, ok := <-q.intake:
+			if !ok {
+				for _, msg := range buf[head:] {
+					logger.output <- msg
+				}
+				close(logger.output)
+				return
+			}
+			if len(buf)-head >= int(atomic.LoadInt64(&q.max)) {
+				logger.drop()
+				continue
+			}
+			if head > 0 && len(buf) == cap(buf) {
+				n := copy(buf, buf[head:])
+				buf, head = buf[:n], 0
+			}
+			buf = append(buf, msg)
+		case out <- next:
+			buf[head] = LogMsg{}
+			head++
+			if head == len(buf) {
+				head = 0
+				if min := int(atomic.LoadInt64(&q.min)); cap(buf) > 2*min {
+					buf = make([]LogMsg, 0, min)
+				} else {
+					buf = buf[:0]
+				}
+			}
+		}
+		atomic.StoreInt64(&q.depth, int64(len(buf)-head))
+	}
+}
@@ -0,0 +1,34 @@
+package liblog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAdaptiveQueue(t *testing.T) {
+	defer quiet()()
+	logger := Init("adaptive")
+	logger.SetSilentDrop(true)
+	logger.SetAdaptiveQueue(2, 10)
+	release := make(chan struct{})
+	var buf syncBuffer
+	logger.AddWriter(blockingWriter{release})
+	logger.AddWriter(&buf)
+
+	for i := 0; i < 50; i++ {
+		logger.Info("message %d", i)
+	}
+	if depth := logger.QueueDepth(); depth < 9 || depth > 10 {
+		t.Errorf("queue depth %d, want at most 10", depth)
+	}
+	close(release)
+	logger.StopSync()
+
+	written := strings.Count(buf.String(), "\n")
+	if written < 10 || written > 12 || uint64(written)+logger.Dropped() != 50 {
+		t.Errorf("%d messages written and %d dropped", written, logger.Dropped())
+	}
+	if !strings.Contains(buf.String(), `"message":"message 0"`) {
+		t.Errorf("oldest message dropped: %s", buf.String())
+	}
+}
@@ -0,0 +1,68 @@
+package liblog
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// BackpressureDebounce is how long a logger must go without dropping,
+// with its queue at most half full, before OnBackpressure reports that it
+// recovered.
+const BackpressureDebounce = time.Second
+
+// OnBackpressure sets a function called with true when the logger starts
+// dropping messages because its queue is full, and with false once it
+// has recovered (see BackpressureDebounce). Drops while it is dropping
+// already do not call it again, so a queue flapping around full gives a
+// single pair of calls. fn runs on a goroutine of its own, never
+// concurrently with itself, and may log. nil removes it.
+func (logger *Logger) OnBackpressure(fn func(dropping bool)) {
+	logger.mu.Lock()
+	logger.backpressure = fn
+	logger.mu.Unlock()
+}
+
+func (logger *Logger) backpressureFunc() func(bool) {
+	logger.mu.RLock()
+	defer logger.mu.RUnlock()
+	return logger.backpressure
+}
+
+// backpressureStarted is called on every drop.
+func (logger *Logger) backpressureStarted() {
+	atomic.StoreInt64(&logger.lastDropAt, time.Now().UnixNano())
+	if atomic.CompareAndSwapInt32(&logger.backpressured, 0, 1) {
+		go logger.watchBackpressure()
+	}
+}
+
+func (logger *Logger) watchBackpressure() {
+	if fn := logger.backpressureFunc(); fn != nil {
+		fn(true)
+	}
+	ticker := time.NewTicker(BackpressureDebounce / 4)
+	defer ticker.Stop()
+	for range ticker.C {
+		logger.closeMu.RLock()
+		closed := logger.closed
+		logger.closeMu.RUnlock()
+		if closed {
+			return
+		}
+		calm := time.Since(time.Unix(0, atomic.LoadInt64(&logger.lastDropAt))) >= BackpressureDebounce
+		if calm && logger.QueueDepth() <= logger.lowWater() {
+			atomic.StoreInt32(&logger.backpressured, 0)
+			if fn := logger.backpressureFunc(); fn != nil {
+				fn(false)
+			}
+			return
+		}
+	}
+}
+
+func (logger *Logger) lowWater() int {
+	if q := logger.adaptiveQueue(); q != nil {
+		return int(atomic.LoadInt64(&q.max)) / 2
+	}
+	return cap(logger.output) / 2
+}
@@ -0,0 +1,40 @@
+package liblog
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestOnBackpressure(t *testing.T) {
+	defer quiet()()
+	os.Setenv("LOG_QUEUE_LEN", "1")
+	defer os.Unsetenv("LOG_QUEUE_LEN")
+
+	logger := Init("backpressure")
+	logger.SetSilentDrop(true)
+	events := make(chan bool, 10)
+	logger.OnBackpressure(func(dropping bool) { events <- dropping })
+	release := make(chan struct{})
+	logger.AddWriter(blockingWriter{release})
+
+	for i := 0; i < 50; i++ {
+		logger.Info("message %d", i)
+	}
+	if dropping := <-events; !dropping {
+		t.Fatal("first event is not the start of dropping")
+	}
+	close(release)
+	select {
+	case dropping := <-events:
+		if dropping {
+			t.Error("second event is not the recovery")
+		}
+	case <-time.After(5 * BackpressureDebounce):
+		t.Fatal("recovery not reported")
+	}
+	logger.StopSync()
+	if len(events) != 0 {
+		t.Errorf("%d extra events", len(events))
+	}
+}
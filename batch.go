@@ -0,0 +1,108 @@
+package liblog
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// BatchSink receives the records collected by a BatchWriter, oldest
+// first, one record per slice element, each with its line terminator.
+// WriteBatch may keep the slices: they are not reused.
+type BatchSink interface {
+	WriteBatch(records [][]byte) error
+}
+
+type batchWriter struct {
+	sink      BatchSink
+	flushSize int
+
+	mu      sync.Mutex
+	records [][]byte
+	closed  bool
+	quit    chan struct{}
+	done    chan struct{}
+}
+
+// BatchWriter returns a writer collecting records for sink, e.g. for
+// multipart uploads or batch inserts, and passing them on once flushSize
+// records are waiting, every flushInterval if there are any, and on
+// Close. Every Write is taken as one record. It is safe for concurrent
+// use and sink is never called concurrently. A Write completing a batch
+// returns the error of sink for that batch; errors of batches passed on
+// by the timer are reported as internal errors. flushInterval <= 0 only
+// flushes by size and on Close.
+func BatchWriter(sink BatchSink, flushSize int, flushInterval time.Duration) io.WriteCloser {
+	if flushSize < 1 {
+		flushSize = 1
+	}
+	b := &batchWriter{sink: sink, flushSize: flushSize, quit: make(chan struct{}), done: make(chan struct{})}
+	if flushInterval > 0 {
+		go b.run(flushInterval)
+	} else {
+		close(b.done)
+	}
+	return b
+}
+
+func (b *batchWriter) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return 0, errors.New("liblog: batch writer closed")
+	}
+	b.records = append(b.records, append([]byte(nil), p...))
+	if len(b.records) >= b.flushSize {
+		if err := b.flush(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// flush passes the waiting records to the sink; b.mu must be held.
+func (b *batchWriter) flush() error {
+	if len(b.records) == 0 {
+		return nil
+	}
+	records := b.records
+	b.records = nil
+	return b.sink.WriteBatch(records)
+}
+
+func (b *batchWriter) run(interval time.Duration) {
+	defer close(b.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.quit:
+			return
+		case <-ticker.C:
+			b.mu.Lock()
+			err := b.flush()
+			b.mu.Unlock()
+			if err != nil {
+				internalf("batch sink %T failed: %v", b.sink, err)
+			}
+		}
+	}
+}
+
+// Close stops the timer and passes the last, possibly partial, batch to
+// the sink, returning its error. Writes after Close fail.
+func (b *batchWriter) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	b.mu.Unlock()
+	close(b.quit)
+	<-b.done
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.flush()
+}
@@ -0,0 +1,62 @@
+package liblog
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type batchRecorder struct {
+	mu      sync.Mutex
+	batches [][]string
+}
+
+func (r *batchRecorder) WriteBatch(records [][]byte) error {
+	batch := make([]string, len(records))
+	for i, record := range records {
+		batch[i] = string(record)
+	}
+	r.mu.Lock()
+	r.batches = append(r.batches, batch)
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *batchRecorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.batches)
+}
+
+func TestBatchWriter(t *testing.T) {
+	sink := &batchRecorder{}
+	w := BatchWriter(sink, 2, 0)
+	for _, record := range []string{"a\n", "b\n", "c\n"} {
+		w.Write([]byte(record))
+	}
+	if n := sink.count(); n != 1 {
+		t.Fatalf("got %d batches before Close, want 1", n)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("d\n")); err == nil {
+		t.Error("write after Close succeeded")
+	}
+	got := make([]string, len(sink.batches))
+	for i, batch := range sink.batches {
+		got[i] = strings.Join(batch, "")
+	}
+	if strings.Join(got, "|") != "a\nb\n|c\n" {
+		t.Errorf("unexpected batches %q", got)
+	}
+}
+
+func TestBatchWriterInterval(t *testing.T) {
+	sink := &batchRecorder{}
+	w := BatchWriter(sink, 100, 10*time.Millisecond)
+	defer w.Close()
+	w.Write([]byte("a\n"))
+	waitFor(t, func() bool { return sink.count() == 1 })
+}
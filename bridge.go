@@ -0,0 +1,119 @@
+package liblog
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var writerSource = []Field{{"source", "writer"}}
+
+type parsingWriter struct {
+	host *Logger
+}
+
+// ParsingWriter returns a writer logging every line written to it at the
+// level named by a leading tag such as "[ERROR]" or "[warn]", with the tag
+// removed. Lines without a recognizable tag are logged at Info level.
+func (logger *Logger) ParsingWriter() io.Writer {
+	return parsingWriter{logger}
+}
+
+func (w parsingWriter) Write(p []byte) (n int, err error) {
+	for _, line := range strings.Split(string(p), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		level, text := parseLevelTag(line)
+		w.host.logDepth(0, level, writerSource, "%s", text)
+	}
+	return len(p), nil
+}
+
+func parseLevelTag(line string) (LogLevel, string) {
+	trimmed := strings.TrimLeft(line, " \t")
+	end := strings.IndexByte(trimmed, ']')
+	if !strings.HasPrefix(trimmed, "[") || end < 2 || trimmed[1] < 'A' {
+		return InfoLevel, line
+	}
+	level, err := ParseLevel(trimmed[1:end])
+	if err != nil {
+		return InfoLevel, line
+	}
+	return level, strings.TrimLeft(trimmed[end+1:], " \t")
+}
+
+// DefaultSeverityKeywords are the line prefixes recognized by the writers
+// of InferLevelWriter unless changed with SetKeywords.
+var DefaultSeverityKeywords = map[string]LogLevel{
+	"DEBUG":   DebugLevel,
+	"INFO":    InfoLevel,
+	"WARN":    WarningLevel,
+	"WARNING": WarningLevel,
+	"ERROR":   ErrorLevel,
+	"FATAL":   FatalLevel,
+}
+
+// InferWriter logs every line written to it at the level named by a
+// leading keyword, like "WARNING:" or "ERROR ", with the keyword removed.
+type InferWriter struct {
+	host         *Logger
+	defaultLevel LogLevel
+	mu           sync.RWMutex
+	keywords     []string
+	levels       map[string]LogLevel
+}
+
+// InferLevelWriter returns an InferWriter logging lines without a known
+// keyword at defaultLevel.
+func (logger *Logger) InferLevelWriter(defaultLevel LogLevel) *InferWriter {
+	w := &InferWriter{host: logger, defaultLevel: defaultLevel}
+	w.SetKeywords(DefaultSeverityKeywords)
+	return w
+}
+
+// SetKeywords replaces the recognized keywords. They are matched case
+// sensitively and must be followed by a colon, a space or the end of the
+// line.
+func (w *InferWriter) SetKeywords(keywords map[string]LogLevel) {
+	sorted := make([]string, 0, len(keywords))
+	levels := make(map[string]LogLevel, len(keywords))
+	for keyword, level := range keywords {
+		sorted = append(sorted, keyword)
+		levels[keyword] = level
+	}
+	// longest first, so that WARNING is not taken for WARN
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	w.mu.Lock()
+	w.keywords, w.levels = sorted, levels
+	w.mu.Unlock()
+}
+
+func (w *InferWriter) Write(p []byte) (n int, err error) {
+	for _, line := range strings.Split(string(p), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		level, text := w.infer(line)
+		w.host.logDepth(0, level, writerSource, "%s", text)
+	}
+	return len(p), nil
+}
+
+func (w *InferWriter) infer(line string) (LogLevel, string) {
+	trimmed := strings.TrimLeft(line, " \t")
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	for _, keyword := range w.keywords {
+		if !strings.HasPrefix(trimmed, keyword) {
+			continue
+		}
+		rest := trimmed[len(keyword):]
+		if rest != "" && rest[0] != ':' && rest[0] != ' ' {
+			continue
+		}
+		return w.levels[keyword], strings.TrimLeft(strings.TrimPrefix(rest, ":"), " \t")
+	}
+	return w.defaultLevel, line
+}
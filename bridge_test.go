@@ -0,0 +1,68 @@
+package liblog
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestParsingWriter(t *testing.T) {
+	defer quiet()()
+	logger := Init("bridge")
+	logger.SetLevel(DebugLevel)
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+
+	io.WriteString(logger.ParsingWriter(), "[ERROR] disk failed\n[warn]  slow disk\n\n[DBG]details\nno tag\n[2] 100%\n[unknown] tag")
+	logger.StopSync()
+
+	want := []string{
+		`"level":"ERROR","message":"disk failed"`,
+		`"level":"WARNING","message":"slow disk"`,
+		`"level":"DEBUG","message":"details"`,
+		`"level":"INFO","message":"no tag"`,
+		`"level":"INFO","message":"[2] 100%"`,
+		`"level":"INFO","message":"[unknown] tag"`,
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines: %s", len(lines), buf.String())
+	}
+	for i, line := range lines {
+		if !strings.Contains(line, want[i]) {
+			t.Errorf("line %d: %s, want %s", i, line, want[i])
+		}
+	}
+}
+
+func TestInferLevelWriter(t *testing.T) {
+	defer quiet()()
+	logger := Init("bridge")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+
+	w := logger.InferLevelWriter(WarningLevel)
+	io.WriteString(w, "ERROR: connection reset\nWARNING:  retrying\nINFOrmation\nplain\n")
+	w.SetKeywords(map[string]LogLevel{"E": ErrorLevel})
+	io.WriteString(w, "E 0102 failed\nERROR: unknown now")
+	logger.StopSync()
+
+	want := []string{
+		`"level":"ERROR","message":"connection reset"`,
+		`"level":"WARNING","message":"retrying"`,
+		`"level":"WARNING","message":"INFOrmation"`,
+		`"level":"WARNING","message":"plain"`,
+		`"level":"ERROR","message":"0102 failed"`,
+		`"level":"WARNING","message":"ERROR: unknown now"`,
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines: %s", len(lines), buf.String())
+	}
+	for i, line := range lines {
+		if !strings.Contains(line, want[i]) {
+			t.Errorf("line %d: %s, want %s", i, line, want[i])
+		}
+	}
+}
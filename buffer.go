@@ -0,0 +1,115 @@
+package liblog
+
+import (
+	"bufio"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type stdoutBuffer struct {
+	mu   sync.Mutex
+	w    *bufio.Writer
+	quit chan struct{}
+}
+
+// write keeps records whole: bufio would otherwise fill the buffer with
+// the start of a record and write the rest separately. A record larger
+// than the buffer is written directly by bufio once the buffer is empty.
+// write reports whether data was buffered; once writing to stdout has
+// failed, every write fails.
+func (buffer *stdoutBuffer) write(data []byte) bool {
+	buffer.mu.Lock()
+	defer buffer.mu.Unlock()
+	if len(data) > buffer.w.Available() && buffer.w.Buffered() > 0 {
+		buffer.w.Flush()
+	}
+	n, err := writeSafe(buffer.w, data)
+	return err == nil && n == len(data)
+}
+
+func (buffer *stdoutBuffer) flush() error {
+	buffer.mu.Lock()
+	defer buffer.mu.Unlock()
+	return buffer.w.Flush()
+}
+
+// loggerStdout lets the buffer follow stdout changes made by
+// RedirectStandardStreams.
+type loggerStdout struct {
+	logger *Logger
+}
+
+func (out loggerStdout) Write(p []byte) (int, error) {
+	return out.logger.stdoutWriter().Write(p)
+}
+
+// SetStdoutBuffer buffers up to size bytes written to stdout, saving a
+// system call per message. The buffer is flushed every flushInterval, by
+// Flush and when the logger stops. Buffered messages are lost if the
+// process exits any other way, e.g. on os.Exit or a crash. A size of 0
+// turns buffering off.
+func (logger *Logger) SetStdoutBuffer(size int, flushInterval time.Duration) {
+	var buffer *stdoutBuffer
+	if size > 0 {
+		buffer = &stdoutBuffer{
+			w:    bufio.NewWriterSize(loggerStdout{logger}, size),
+			quit: make(chan struct{}),
+		}
+	}
+
+	logger.mu.Lock()
+	prev := logger.buffer
+	logger.buffer = buffer
+	logger.mu.Unlock()
+
+	if prev != nil {
+		close(prev.quit)
+		prev.flush()
+	}
+	if buffer != nil && flushInterval > 0 {
+		go logger.flushEvery(buffer, flushInterval)
+	}
+}
+
+func (logger *Logger) flushEvery(buffer *stdoutBuffer, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			buffer.flush()
+		case <-buffer.quit:
+			return
+		case <-logger.done:
+			return
+		}
+	}
+}
+
+// Flush writes out messages held by the stdout buffer.
+func (logger *Logger) Flush() error {
+	if buffer := logger.stdoutBuffer(); buffer != nil {
+		return buffer.flush()
+	}
+	return nil
+}
+
+func (logger *Logger) stdoutBuffer() *stdoutBuffer {
+	logger.mu.RLock()
+	defer logger.mu.RUnlock()
+	return logger.buffer
+}
+
+// SetSplitStreams sends Warning and Error records to standard error
+// instead of stdout (or the output chosen with LOGOUTPUT or WithOutput),
+// as container platforms expect. Standard error is os.Stderr as of Init,
+// or the writer set with WithErrorOutput. Writers added with AddWriter
+// still get every record.
+func (logger *Logger) SetSplitStreams(split bool) {
+	var v int32
+	if split {
+		v = 1
+	}
+	atomic.StoreInt32(&logger.splitStreams, v)
+}
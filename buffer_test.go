@@ -0,0 +1,133 @@
+package liblog
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestStdoutBuffer(t *testing.T) {
+	var stdout, writer syncBuffer
+	logger := Init("buffer")
+	logger.setStdout(&stdout)
+	logger.AddWriter(&writer)
+	logger.SetStdoutBuffer(4096, 0)
+
+	logger.Info("buffered")
+	waitFor(t, func() bool { return strings.Contains(writer.String(), "buffered") })
+	if stdout.String() != "" {
+		t.Errorf("written before flush: %s", stdout.String())
+	}
+	logger.StopSync()
+	if !strings.Contains(stdout.String(), "buffered") {
+		t.Errorf("not flushed on StopSync: %s", stdout.String())
+	}
+}
+
+func TestStdoutBufferInterval(t *testing.T) {
+	var stdout syncBuffer
+	logger := Init("buffer")
+	defer logger.StopSync()
+	logger.setStdout(&stdout)
+	logger.SetStdoutBuffer(4096, time.Millisecond)
+
+	logger.Info("flushed by timer")
+	waitFor(t, func() bool { return strings.Contains(stdout.String(), "flushed by timer") })
+}
+
+type chunkWriter struct {
+	chunks []string
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	w.chunks = append(w.chunks, string(p))
+	return len(p), nil
+}
+
+func TestRecordPerWrite(t *testing.T) {
+	for _, formatter := range []Formatter{JSONFormatter{}, LogfmtFormatter{}, ConsoleFormatter{}} {
+		stdout, writer := new(chunkWriter), new(chunkWriter)
+		logger := Init("records", WithSynchronous(), WithFormatter(formatter), WithOutput(stdout))
+		logger.AddWriter(writer)
+		logger.SetStdoutBuffer(300, 0)
+		for _, n := range []int{10, 100, 250, 400, 5, 120} {
+			logger.Info("%s", strings.Repeat("x", n))
+		}
+		logger.StopSync()
+
+		for _, chunk := range writer.chunks {
+			if strings.Count(chunk, "\n") != 1 || !strings.HasSuffix(chunk, "\n") {
+				t.Errorf("%T: write is not one record: %q", formatter, chunk)
+			}
+		}
+		for _, chunk := range stdout.chunks {
+			if !strings.HasSuffix(chunk, "\n") {
+				t.Errorf("%T: buffered write splits a record: %q", formatter, chunk)
+			}
+		}
+		if len(writer.chunks) != 6 || strings.Join(stdout.chunks, "") != strings.Join(writer.chunks, "") {
+			t.Errorf("%T: buffered output differs", formatter)
+		}
+	}
+}
+
+func TestSplitStreams(t *testing.T) {
+	defer quiet()()
+	var stdout bytes.Buffer
+	stderr, err := ioutil.TempFile("", "stderr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(stderr.Name())
+	defer stderr.Close()
+	os.Stderr = stderr
+
+	logger := Init("split", WithSynchronous(), WithOutput(&stdout))
+	logger.Info("before")
+	logger.Error("before")
+	logger.SetSplitStreams(true)
+	logger.Info("info")
+	logger.Warning("warning")
+	logger.Error("error")
+	logger.StopSync()
+
+	errOut, _ := ioutil.ReadFile(stderr.Name())
+	if n := strings.Count(stdout.String(), "\n"); n != 3 || !strings.Contains(stdout.String(), `"message":"info"`) {
+		t.Errorf("stdout: %s", stdout.String())
+	}
+	if n := strings.Count(string(errOut), "\n"); n != 2 || strings.Contains(string(errOut), `"level":"INFO"`) {
+		t.Errorf("stderr: %s", errOut)
+	}
+}
@@ -0,0 +1,77 @@
+package liblog
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// Clone returns a logger with the configuration of logger (module, level,
+// formatter, writers and so on) but its own queue and worker goroutine,
+// so that changing or stopping one does not affect the other. Unlike
+// Named and With, every clone costs a goroutine until it is stopped.
+// Writers are shared, not copied: a writer closed by one logger is closed
+// for the other too, including the LOGOUTPUT file, which stays owned by
+// the original logger. A level shared with WithSharedLevel stays shared,
+// and the large message sink is shared too. The clone gets a ring buffer
+// and an error context buffer of its own, of the same size, empty.
+func (logger *Logger) Clone() *Logger {
+	c := new(core)
+	c.dropNoticeEvery = atomic.LoadInt64(&logger.dropNoticeEvery)
+	c.captureGoid = atomic.LoadInt32(&logger.captureGoid)
+	c.captureFunc = atomic.LoadInt32(&logger.captureFunc)
+	c.silentDrop = atomic.LoadInt32(&logger.silentDrop)
+	c.splitStreams = atomic.LoadInt32(&logger.splitStreams)
+	c.noFailsafe = atomic.LoadInt32(&logger.noFailsafe)
+	c.signalReraise = atomic.LoadInt32(&logger.signalReraise)
+	c.versionField = atomic.LoadInt32(&logger.versionField)
+	c.pauseBypass = atomic.LoadInt32(&logger.pauseBypass)
+	c.syncFrom = atomic.LoadInt32(&logger.syncFrom)
+	c.writePolicy = atomic.LoadInt32(&logger.writePolicy)
+	c.maxMsgBytes = atomic.LoadInt64(&logger.maxMsgBytes)
+	c.maxStackDepth = atomic.LoadInt64(&logger.maxStackDepth)
+	c.preAlloc = atomic.LoadInt64(&logger.preAlloc)
+	c.structuredStacks = atomic.LoadInt32(&logger.structuredStacks)
+	c.Level = logger.GetLevel()
+	c.sharedLevel = logger.sharedLevel
+	c.msgLen = logger.msgLen
+	c.writerLevel = LogLevel(atomic.LoadInt32((*int32)(&logger.writerLevel)))
+	c.synchronous = logger.synchronous
+	c.dropFull = logger.dropFull
+	c.throttle.size = logger.throttle.getSize()
+	logger.health.mu.Lock()
+	c.health.maxDrops, c.health.window = logger.health.maxDrops, logger.health.window
+	logger.health.mu.Unlock()
+	logger.pause.mu.Lock()
+	c.pause.policy, c.pause.size = logger.pause.policy, logger.pause.size
+	logger.pause.mu.Unlock()
+
+	logger.mu.RLock()
+	c.module = logger.module
+	c.id = logger.id
+	c.writers = append([]io.Writer(nil), logger.writers...)
+	c.stdout = logger.stdout
+	c.stderr = logger.stderr
+	c.format = logger.format
+	c.formatter = logger.formatter
+	c.hook = logger.hook
+	c.redactor = logger.redactor
+	c.defaults = logger.defaults
+	c.routes = append([]*route(nil), logger.routes...)
+	c.exitFunc = logger.exitFunc
+	c.backpressure = logger.backpressure
+	c.large = logger.large
+	if logger.ring != nil {
+		c.ring = &ring{level: atomic.LoadInt32(&logger.ring.level), records: make([]string, len(logger.ring.records))}
+	}
+	if logger.errorContext != nil {
+		c.errorContext = &errorContext{records: make([]heldRecord, len(logger.errorContext.records))}
+	}
+	logger.mu.RUnlock()
+
+	clone := &Logger{core: c, name: logger.name, fields: logger.fields, groups: logger.groups, timeFormat: logger.timeFormat, scopeLevel: logger.scopeLevel}
+	clone.start(cap(logger.output))
+	if q := logger.adaptiveQueue(); q != nil {
+		clone.SetAdaptiveQueue(int(atomic.LoadInt64(&q.min)), int(atomic.LoadInt64(&q.max)))
+	}
+	return clone
+}
@@ -0,0 +1,55 @@
+package liblog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	defer quiet()()
+	logger := Init("clone")
+	logger.SetLevel(WarningLevel)
+	logger.SetFormatter(LogfmtFormatter{})
+	var shared, own bytes.Buffer
+	logger.AddWriter(&shared)
+
+	clone := logger.Named("sub").Clone()
+	clone.AddWriter(&own)
+	clone.SetLevel(InfoLevel)
+	logger.StopSync()
+
+	clone.Info("from clone")
+	clone.StopSync()
+
+	if shared.Len() != own.Len() || !strings.Contains(own.String(), `level=INFO message="from clone" service=clone logger=sub`) {
+		t.Errorf("clone output: %q, shared writer: %q", own.String(), shared.String())
+	}
+	if logger.GetLevel() != WarningLevel || len(logger.Writers()) != 1 {
+		t.Errorf("clone changed the original logger")
+	}
+}
+
+func TestCloneBuffers(t *testing.T) {
+	defer quiet()()
+	level := NewAtomicLevel(InfoLevel)
+	var sink bytes.Buffer
+	logger := Init("clone", WithSharedLevel(level), WithSynchronous())
+	logger.EnableRingBuffer(4)
+	logger.SetLargeMessageSink(200, &sink)
+	logger.Info("original")
+
+	clone := logger.Clone()
+	level.Set(ErrorLevel)
+	clone.Warning("filtered")
+	clone.Error("dump: %s", strings.Repeat("x", 300))
+	clone.StopSync()
+	logger.StopSync()
+
+	if recent := clone.DumpRecent(); len(recent) != 1 || !strings.Contains(recent[0], "large payload") {
+		t.Errorf("clone ring buffer: %q", recent)
+	}
+	if len(logger.DumpRecent()) != 1 || !strings.Contains(sink.String(), "dump: xxx") {
+		t.Errorf("original ring buffer: %q, sink: %q", logger.DumpRecent(), sink.String())
+	}
+}
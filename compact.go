@@ -0,0 +1,49 @@
+package liblog
+
+import (
+	"bytes"
+	"strings"
+)
+
+// CompactFormatter writes the shortest useful record for constrained
+// sinks: a one-letter level code and the message, e.g. "E|disk full".
+// Module, source location and fields are left out.
+type CompactFormatter struct {
+	// Delimiter separates the parts of a record, "|" when empty
+	Delimiter string
+	// Timestamp puts the time, in the logger's time format, first
+	Timestamp bool
+}
+
+var levelCodes = map[LogLevel]byte{
+	DebugLevel:   'D',
+	InfoLevel:    'I',
+	WarningLevel: 'W',
+	ErrorLevel:   'E',
+	FatalLevel:   'F',
+}
+
+// newlines are replaced so that every record stays on one line
+var compactNewlines = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ")
+
+func (f CompactFormatter) Format(buf *bytes.Buffer, msg *LogMsg, opts FormatOptions) error {
+	delimiter := f.Delimiter
+	if delimiter == "" {
+		delimiter = "|"
+	}
+	if f.Timestamp {
+		var scratch [64]byte
+		ts, _ := appendTime(scratch[:0], msg.Timestamp, opts.TimeFormat)
+		buf.Write(ts)
+		buf.WriteString(delimiter)
+	}
+	code, ok := levelCodes[msg.Level]
+	if !ok {
+		code = '?'
+	}
+	buf.WriteByte(code)
+	buf.WriteString(delimiter)
+	compactNewlines.WriteString(buf, msg.Message)
+	buf.WriteString(opts.Terminator())
+	return nil
+}
@@ -0,0 +1,21 @@
+package liblog
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCompactFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	msg := LogMsg{Timestamp: textMsg.Timestamp, Level: ErrorLevel, Message: "disk\nfull", Module: "api"}
+	CompactFormatter{}.Format(&buf, &msg, FormatOptions{})
+	if buf.String() != "E|disk full\n" {
+		t.Errorf("got %q", buf.String())
+	}
+
+	buf.Reset()
+	CompactFormatter{Delimiter: " ", Timestamp: true}.Format(&buf, &msg, FormatOptions{TimeFormat: TimeEpoch})
+	if buf.String() != "1614852672 E disk full\n" {
+		t.Errorf("got %q", buf.String())
+	}
+}
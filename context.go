@@ -0,0 +1,172 @@
+package liblog
+
+import (
+	"context"
+	"encoding/base64"
+	"math"
+	"strconv"
+	"sync"
+)
+
+type contextKey struct{}
+
+type correlationKey string
+
+const (
+	requestIDKey correlationKey = "request_id"
+	traceIDKey   correlationKey = "trace_id"
+	userIDKey    correlationKey = "user_id"
+)
+
+type contextField struct {
+	key  interface{}
+	name string
+}
+
+var contextFieldKeys = struct {
+	sync.RWMutex
+	list []contextField
+}{list: []contextField{
+	{requestIDKey, "request_id"},
+	{traceIDKey, "trace_id"},
+	{userIDKey, "user_id"},
+}}
+
+// RegisterContextField makes the *Ctx log functions write the value stored
+// in their context under key as a field called name.
+func RegisterContextField(key interface{}, name string) {
+	contextFieldKeys.Lock()
+	contextFieldKeys.list = append(contextFieldKeys.list, contextField{key, name})
+	contextFieldKeys.Unlock()
+}
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+func WithTraceID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, traceIDKey, id)
+}
+
+func WithUserID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, userIDKey, id)
+}
+
+// Any builds a field from a key and an arbitrary value.
+func Any(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Binary builds a field holding b as a standard base64 string, which keeps
+// binary data valid in every format at the cost of a third more bytes.
+// Fields are not limited by SetMaxMessageBytes, so large payloads are
+// better cut before logging.
+func Binary(key string, b []byte) Field {
+	return Field{Key: key, Value: base64.StdEncoding.EncodeToString(b)}
+}
+
+// Float builds a field holding v as a decimal number with precision
+// digits after the point, never in exponent form; a negative precision
+// uses as many digits as needed. NaN and infinities are written as the
+// strings "NaN", "+Inf" and "-Inf".
+func Float(key string, v float64, precision int) Field {
+	return Field{Key: key, Value: decimal{v, precision}}
+}
+
+type decimal struct {
+	v         float64
+	precision int
+}
+
+func (d decimal) String() string {
+	if math.IsNaN(d.v) || math.IsInf(d.v, 0) {
+		return strconv.FormatFloat(d.v, 'g', -1, 64)
+	}
+	return strconv.FormatFloat(d.v, 'f', d.precision, 64)
+}
+
+func (d decimal) MarshalJSON() ([]byte, error) {
+	if math.IsNaN(d.v) || math.IsInf(d.v, 0) {
+		return []byte(`"` + d.String() + `"`), nil
+	}
+	return []byte(d.String()), nil
+}
+
+// ContextWith returns a copy of ctx carrying fields in addition to the ones
+// ctx already carries. The fields are only written by the *Ctx log
+// functions: logging without passing ctx leaves them out.
+func ContextWith(ctx context.Context, fields ...Field) context.Context {
+	parent := contextFields(ctx)
+	merged := make([]Field, 0, len(parent)+len(fields))
+	merged = append(merged, parent...)
+	merged = append(merged, fields...)
+	return context.WithValue(ctx, contextKey{}, merged)
+}
+
+func contextFields(ctx context.Context) []Field {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(contextKey{}).([]Field)
+	var keyed []Field
+	contextFieldKeys.RLock()
+	for _, f := range contextFieldKeys.list {
+		if value := ctx.Value(f.key); value != nil {
+			keyed = append(keyed, Field{f.name, value})
+		}
+	}
+	contextFieldKeys.RUnlock()
+	if keyed == nil {
+		return fields
+	}
+	return append(keyed, fields...)
+}
+
+func (logger *Logger) DebugCtx(ctx context.Context, format string, values ...interface{}) {
+	logger.log(DebugLevel, contextFields(ctx), format, values...)
+}
+
+func (logger *Logger) InfoCtx(ctx context.Context, format string, values ...interface{}) {
+	logger.log(InfoLevel, contextFields(ctx), format, values...)
+}
+
+func (logger *Logger) WarningCtx(ctx context.Context, format string, values ...interface{}) {
+	logger.log(WarningLevel, contextFields(ctx), format, values...)
+}
+
+func (logger *Logger) ErrorCtx(ctx context.Context, format string, values ...interface{}) {
+	logger.log(ErrorLevel, contextFields(ctx), format, values...)
+}
+
+// LogKVCtx logs msg as is at level with the fields carried by ctx followed
+// by fields built from alternating keys and values like With. A key found
+// in both is written once, with the value from keyvals.
+func (logger *Logger) LogKVCtx(ctx context.Context, level LogLevel, msg string, keyvals ...interface{}) {
+	fields := contextFields(ctx)
+	fields = append(fields[:len(fields):len(fields)], keyvalFields(keyvals)...)
+	logger.log(level, fields, "%s", msg)
+}
+
+func DebugCtx(ctx context.Context, format string, values ...interface{}) {
+	if singleLogger != nil {
+		singleLogger.log(DebugLevel, contextFields(ctx), format, values...)
+	}
+}
+
+func InfoCtx(ctx context.Context, format string, values ...interface{}) {
+	if singleLogger != nil {
+		singleLogger.log(InfoLevel, contextFields(ctx), format, values...)
+	}
+}
+
+func WarningCtx(ctx context.Context, format string, values ...interface{}) {
+	if singleLogger != nil {
+		singleLogger.log(WarningLevel, contextFields(ctx), format, values...)
+	}
+}
+
+func ErrorCtx(ctx context.Context, format string, values ...interface{}) {
+	if singleLogger != nil {
+		singleLogger.log(ErrorLevel, contextFields(ctx), format, values...)
+	}
+}
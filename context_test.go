@@ -0,0 +1,65 @@
+package liblog
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestContextFields(t *testing.T) {
+	defer quiet()()
+	logger := Init("context")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+
+	ctx := ContextWith(context.Background(), Any("request_id", "r1"))
+	child := ContextWith(ctx, Any("user", 7))
+	logger.InfoCtx(child, "child")
+	logger.InfoCtx(ctx, "parent")
+	logger.Info("plain")
+	logger.StopSync()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines: %s", len(lines), buf.String())
+	}
+	if !strings.HasSuffix(lines[0], `"request_id":"r1","user":7}`) {
+		t.Errorf("child context fields missing: %s", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], `"request_id":"r1"}`) {
+		t.Errorf("parent context fields wrong: %s", lines[1])
+	}
+	if strings.Contains(lines[2], "request_id") {
+		t.Errorf("fields leaked without ctx: %s", lines[2])
+	}
+}
+
+type tenantKey struct{}
+
+func TestContextCorrelationIDs(t *testing.T) {
+	defer quiet()()
+	RegisterContextField(tenantKey{}, "tenant")
+	defer func() {
+		contextFieldKeys.Lock()
+		contextFieldKeys.list = contextFieldKeys.list[:len(contextFieldKeys.list)-1]
+		contextFieldKeys.Unlock()
+	}()
+	logger := Init("context")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+
+	ctx := WithUserID(WithTraceID(WithRequestID(context.Background(), "r1"), "t1"), "u1")
+	ctx = context.WithValue(ctx, tenantKey{}, "acme")
+	logger.InfoCtx(ContextWith(ctx, Any("extra", true)), "ids")
+	logger.InfoCtx(WithTraceID(context.Background(), "t2"), "trace only")
+	logger.StopSync()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if !strings.HasSuffix(lines[0], `"request_id":"r1","trace_id":"t1","user_id":"u1","tenant":"acme","extra":true}`) {
+		t.Errorf("correlation fields wrong: %s", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], `,"trace_id":"t2"}`) {
+		t.Errorf("trace id wrong: %s", lines[1])
+	}
+}
@@ -0,0 +1,34 @@
+package liblog
+
+import (
+	"io/ioutil"
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	defaultLogger atomic.Value // *Logger
+	discardOnce   sync.Once
+	discard       *Logger
+)
+
+// SetDefault makes logger the one returned by Default, for libraries that
+// accept a logger but fall back to a global one. It is independent of the
+// singleton set up by InitSingleStr. nil goes back to the discard logger.
+func SetDefault(logger *Logger) {
+	defaultLogger.Store(logger)
+}
+
+// Default returns the logger set with SetDefault, or, when there is none,
+// a logger discarding every message, so that it is never nil.
+func Default() *Logger {
+	if logger, _ := defaultLogger.Load().(*Logger); logger != nil {
+		return logger
+	}
+	discardOnce.Do(func() {
+		discard = Init("discard", WithSynchronous(), WithOutput(ioutil.Discard))
+		discard.SetLevel(LogLevel(math.MaxInt32))
+	})
+	return discard
+}
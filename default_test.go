@@ -0,0 +1,33 @@
+package liblog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDefault(t *testing.T) {
+	defer quiet()()
+	discard := Default()
+	if discard == nil || discard.Enabled(FatalLevel) {
+		t.Fatal("unset default is not a discard logger")
+	}
+	discard.Error("nowhere")
+
+	var buf bytes.Buffer
+	logger := Init("default", WithSynchronous(), WithOutput(&buf))
+	SetDefault(logger)
+	defer SetDefault(nil)
+	if Default() != logger || Singleton() == logger {
+		t.Fatal("SetDefault not applied or mixed with the singleton")
+	}
+	Default().Info("injected")
+	if !strings.Contains(buf.String(), `"message":"injected"`) {
+		t.Errorf("default logger not used: %s", buf.String())
+	}
+
+	SetDefault(nil)
+	if Default() != discard {
+		t.Error("SetDefault(nil) did not restore the discard logger")
+	}
+}
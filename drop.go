@@ -0,0 +1,54 @@
+package liblog
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Dropped returns the number of messages dropped because the output queue
+// was full. Messages are only dropped when LOG_QUEUE_LEN is set.
+func (logger *Logger) Dropped() uint64 {
+	return atomic.LoadUint64(&logger.dropped)
+}
+
+// SetDropNoticeInterval limits how often dropped messages are reported
+// as liblog diagnostics (once per second by default). Each notice
+// carries the number of messages dropped since the previous one; drops
+// after the last notice only show up in Dropped.
+func (logger *Logger) SetDropNoticeInterval(d time.Duration) {
+	atomic.StoreInt64(&logger.dropNoticeEvery, int64(d))
+}
+
+// SetSilentDrop stops dropped messages from being reported anywhere; they
+// are still counted by Dropped.
+func (logger *Logger) SetSilentDrop(silent bool) {
+	var v int32
+	if silent {
+		v = 1
+	}
+	atomic.StoreInt32(&logger.silentDrop, v)
+}
+
+func (logger *Logger) drop() {
+	total := atomic.AddUint64(&logger.dropped, 1)
+	logger.health.dropped()
+	logger.backpressureStarted()
+	if atomic.LoadInt32(&logger.silentDrop) == 1 {
+		return
+	}
+	now := time.Now().UnixNano()
+	last := atomic.LoadInt64(&logger.dropNoticeAt)
+	if last != 0 && now-last < atomic.LoadInt64(&logger.dropNoticeEvery) {
+		return
+	}
+	if !atomic.CompareAndSwapInt64(&logger.dropNoticeAt, last, now) {
+		return
+	}
+	count := total - atomic.SwapUint64(&logger.dropReported, total)
+	if last == 0 {
+		internalf("channel is full, dropped %d messages", count)
+		return
+	}
+	elapsed := time.Duration(now - last).Round(time.Millisecond)
+	internalf("dropped %d messages in the last %s", count, elapsed)
+}
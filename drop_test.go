@@ -0,0 +1,67 @@
+package liblog
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+type blockingWriter struct{ release chan struct{} }
+
+func (w blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	return len(p), nil
+}
+
+func TestDropNotice(t *testing.T) {
+	defer quiet()()
+	var notices bytes.Buffer
+	log.SetOutput(&notices)
+	os.Setenv("LOG_QUEUE_LEN", "1")
+	defer os.Unsetenv("LOG_QUEUE_LEN")
+
+	logger := Init("drop")
+	logger.SetDropNoticeInterval(time.Hour)
+	release := make(chan struct{})
+	logger.AddWriter(blockingWriter{release})
+
+	for i := 0; i < 100; i++ {
+		logger.Info("message %d", i)
+	}
+	close(release)
+	logger.StopSync()
+
+	// the worker holds one message and the queue another
+	if dropped := logger.Dropped(); dropped < 98 {
+		t.Errorf("dropped %d messages, want at least 98", dropped)
+	}
+	if n := strings.Count(notices.String(), "liblog:"); n != 1 {
+		t.Errorf("got %d drop notices, want 1: %s", n, notices.String())
+	}
+}
+
+func TestSilentDrop(t *testing.T) {
+	defer quiet()()
+	var notices bytes.Buffer
+	SetInternalErrorWriter(&notices)
+	defer SetInternalErrorWriter(nil)
+	os.Setenv("LOG_QUEUE_LEN", "1")
+	defer os.Unsetenv("LOG_QUEUE_LEN")
+
+	logger := Init("drop")
+	logger.SetSilentDrop(true)
+	release := make(chan struct{})
+	logger.AddWriter(blockingWriter{release})
+	for i := 0; i < 10; i++ {
+		logger.Info("message %d", i)
+	}
+	close(release)
+	logger.StopSync()
+
+	if logger.Dropped() == 0 || notices.Len() != 0 {
+		t.Errorf("dropped %d messages, notices %q", logger.Dropped(), notices.String())
+	}
+}
@@ -0,0 +1,91 @@
+package liblog
+
+import (
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// The warnings about LOGFORMAT, LOGTIME and LOGOUTPUT are given once per
+// process rather than by every Init.
+var logformatWarning, logtimeWarning, logoutputWarning sync.Once
+
+// formatterFromEnv returns the formatter chosen by LOGFORMAT, nil for the
+// default one.
+func formatterFromEnv() Formatter {
+	switch name := os.Getenv("LOGFORMAT"); strings.ToLower(name) {
+	case "", "json":
+	case "logfmt":
+		return LogfmtFormatter{}
+	case "console":
+		return ConsoleFormatter{}
+	case "auto":
+		// decided by Init once the output is known
+	default:
+		logformatWarning.Do(func() {
+			internalf("unknown LOGFORMAT %q, using json", name)
+		})
+	}
+	return nil
+}
+
+// timeFormatFromEnv returns the time format chosen by LOGTIME.
+func timeFormatFromEnv() TimeFormat {
+	name := os.Getenv("LOGTIME")
+	if name == "" {
+		return TimeRFC3339Nano
+	}
+	format, ok := timeFormatNames[strings.ToLower(name)]
+	if !ok {
+		logtimeWarning.Do(func() {
+			internalf("unknown LOGTIME %q, using rfc3339nano", name)
+		})
+	}
+	return format
+}
+
+// AutoFormat switches to colored ConsoleFormatter output when the logger
+// writes to a terminal, JSONFormatter otherwise. LOGFORMAT, when set to
+// anything but "auto", takes precedence; LOGFORMAT=auto has the same
+// effect as calling AutoFormat.
+func (logger *Logger) AutoFormat() {
+	if name := strings.ToLower(os.Getenv("LOGFORMAT")); name != "" && name != "auto" {
+		return
+	}
+	if isTerminal(logger.stdoutWriter()) {
+		logger.SetFormatter(ConsoleFormatter{Color: true})
+	} else {
+		logger.SetFormatter(JSONFormatter{})
+	}
+}
+
+func isTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// outputFromEnv returns the output chosen by LOGOUTPUT, nil for stdout.
+// Files are opened for appending and returned as the closer too.
+func outputFromEnv() (io.Writer, io.Closer) {
+	switch path := os.Getenv("LOGOUTPUT"); path {
+	case "", "stdout":
+	case "stderr":
+		return os.Stderr, nil
+	default:
+		file, err := openAppend(path)
+		if err != nil {
+			logoutputWarning.Do(func() {
+				internalf("cannot open LOGOUTPUT, using stdout: %v", err)
+			})
+			return nil, nil
+		}
+		output := &reopenFile{path: path, file: file}
+		return output, output
+	}
+	return nil, nil
+}
@@ -0,0 +1,104 @@
+package liblog
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestEnvFormatAndOutput(t *testing.T) {
+	defer quiet()()
+	dir, err := ioutil.TempDir("", "liblog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "out.log")
+	os.Setenv("LOGFORMAT", "logfmt")
+	os.Setenv("LOGOUTPUT", path)
+	defer os.Unsetenv("LOGFORMAT")
+	defer os.Unsetenv("LOGOUTPUT")
+
+	logger := Init("env")
+	logger.Info("to file")
+	logger.StopSync()
+	data, _ := ioutil.ReadFile(path)
+	if !strings.Contains(string(data), "level=INFO message=\"to file\" service=env") {
+		t.Errorf("unexpected file content: %s", data)
+	}
+
+	var buf bytes.Buffer
+	logger = Init("env", WithFormatter(JSONFormatter{}), WithOutput(&buf))
+	logger.Info("options win")
+	logger.StopSync()
+	if !strings.Contains(buf.String(), `"message":"options win"`) {
+		t.Errorf("options ignored: %s", buf.String())
+	}
+}
+
+func TestEnvInvalidValues(t *testing.T) {
+	defer quiet()()
+	var notices bytes.Buffer
+	log.SetOutput(&notices)
+	os.Setenv("LOGFORMAT", "xml")
+	os.Setenv("LOGOUTPUT", "/nonexistent/dir/out.log")
+	defer os.Unsetenv("LOGFORMAT")
+	defer os.Unsetenv("LOGOUTPUT")
+	logformatWarning, logoutputWarning = sync.Once{}, sync.Once{}
+
+	logger := Init("env")
+	defer logger.StopSync()
+	if _, ok := logger.currentFormatter().(JSONFormatter); !ok {
+		t.Errorf("formatter %T, want JSONFormatter", logger.currentFormatter())
+	}
+	if logger.stdoutWriter() != os.Stdout {
+		t.Errorf("output not reset to stdout")
+	}
+	if !strings.Contains(notices.String(), `unknown LOGFORMAT "xml"`) || !strings.Contains(notices.String(), "cannot open LOGOUTPUT") {
+		t.Errorf("missing warnings: %s", notices.String())
+	}
+
+	notices.Reset()
+	Init("env").StopSync()
+	if notices.Len() != 0 {
+		t.Errorf("warnings repeated: %s", notices.String())
+	}
+}
+
+func TestAutoFormat(t *testing.T) {
+	defer quiet()()
+	var buf bytes.Buffer
+	logger := Init("auto", WithOutput(&buf))
+	defer logger.StopSync()
+	logger.SetFormatter(LogfmtFormatter{})
+	logger.AutoFormat()
+	if _, ok := logger.currentFormatter().(JSONFormatter); !ok {
+		t.Errorf("formatter %T for a buffer, want JSONFormatter", logger.currentFormatter())
+	}
+
+	os.Setenv("LOGFORMAT", "logfmt")
+	defer os.Unsetenv("LOGFORMAT")
+	logger.SetFormatter(ConsoleFormatter{})
+	logger.AutoFormat()
+	if _, ok := logger.currentFormatter().(ConsoleFormatter); !ok {
+		t.Errorf("LOGFORMAT ignored: %T", logger.currentFormatter())
+	}
+
+	os.Setenv("LOGFORMAT", "auto")
+	auto := Init("auto", WithOutput(&buf))
+	defer auto.StopSync()
+	if _, ok := auto.currentFormatter().(JSONFormatter); !ok {
+		t.Errorf("LOGFORMAT=auto gave %T", auto.currentFormatter())
+	}
+	if tty, err := os.Open("/dev/tty"); err == nil {
+		defer tty.Close()
+		if !isTerminal(tty) {
+			t.Errorf("/dev/tty not detected as a terminal")
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package liblog
+
+import (
+	"errors"
+	"reflect"
+	"runtime"
+)
+
+// Err logs at Error level with err in an "error" field. When err wraps
+// other errors (see errors.Unwrap), "error_chain" lists the messages of
+// err and of every error it wraps, outermost first. When an error of the
+// chain has a stack trace in the style of github.com/pkg/errors (a
+// StackTrace method returning a slice of program counters), the one
+// closest to the cause is written in "error_stack", formatted like the
+// stacks of ErrorStack. A nil err only logs the message.
+func (logger *Logger) Err(err error, format string, values ...interface{}) {
+	logger.log(ErrorLevel, logger.errorFields(err), format, values...)
+}
+
+func (logger *Logger) errorFields(err error) []Field {
+	if err == nil {
+		return nil
+	}
+	fields := []Field{{"error", err.Error()}}
+	var chain []string
+	var stack []StackFrame
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		chain = append(chain, e.Error())
+		if frames := errorStack(e); frames != nil {
+			stack = frames
+		}
+	}
+	if len(chain) > 1 {
+		fields = append(fields, Field{"error_chain", chain})
+	}
+	if stack != nil {
+		if depth := logger.stackDepth(); len(stack) > depth {
+			stack = stack[:depth]
+		}
+		field := logger.stackField(stack)
+		field.Key = "error_stack"
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// errorStack returns the frames of err if it has a StackTrace method
+// returning a slice of uintptr-based program counters, like the errors
+// of github.com/pkg/errors, nil otherwise.
+func errorStack(err error) []StackFrame {
+	method := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+		return nil
+	}
+	out := method.Type().Out(0)
+	if out.Kind() != reflect.Slice || out.Elem().Kind() != reflect.Uintptr {
+		return nil
+	}
+	trace := method.Call(nil)[0]
+	if trace.Len() == 0 {
+		return nil
+	}
+	pcs := make([]uintptr, trace.Len())
+	for i := range pcs {
+		pcs[i] = uintptr(trace.Index(i).Uint())
+	}
+	var frames []StackFrame
+	iter := runtime.CallersFrames(pcs)
+	for {
+		frame, more := iter.Next()
+		frames = append(frames, StackFrame{frame.Function, frame.File, frame.Line})
+		if !more {
+			return frames
+		}
+	}
+}
+
+func Err(err error, format string, values ...interface{}) {
+	if singleLogger != nil {
+		singleLogger.log(ErrorLevel, singleLogger.errorFields(err), format, values...)
+	}
+}
@@ -0,0 +1,69 @@
+package liblog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// tracedError mimics the errors of github.com/pkg/errors.
+type tracedError struct {
+	msg   string
+	trace []frame
+}
+
+type frame uintptr
+
+func (e tracedError) Error() string       { return e.msg }
+func (e tracedError) StackTrace() []frame { return e.trace }
+
+func newTracedError(msg string) error {
+	pcs := make([]uintptr, 8)
+	n := runtime.Callers(1, pcs)
+	trace := make([]frame, n)
+	for i, pc := range pcs[:n] {
+		trace[i] = frame(pc)
+	}
+	return tracedError{msg, trace}
+}
+
+func TestErr(t *testing.T) {
+	defer quiet()()
+	logger := Init("err")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+	logger.SetStructuredStacks(true)
+
+	cause := newTracedError("connection refused")
+	logger.Err(fmt.Errorf("save user: %w", fmt.Errorf("query: %w", cause)), "request failed")
+	logger.Err(errors.New("plain"), "plain failure")
+	logger.Err(nil, "no error")
+	logger.StopSync()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var wrapped struct {
+		Error      string
+		ErrorChain []string     `json:"error_chain"`
+		ErrorStack []StackFrame `json:"error_stack"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &wrapped); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"save user: query: connection refused", "query: connection refused", "connection refused"}
+	if wrapped.Error != want[0] || fmt.Sprint(wrapped.ErrorChain) != fmt.Sprint(want) {
+		t.Errorf("unexpected error fields: %s", lines[0])
+	}
+	if len(wrapped.ErrorStack) == 0 || wrapped.ErrorStack[0].Func != "github.com/wimark/liblog.newTracedError" {
+		t.Errorf("cause stack missing: %s", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], `"error":"plain"}`) {
+		t.Errorf("plain error: %s", lines[1])
+	}
+	if strings.Contains(lines[2], `"error"`) {
+		t.Errorf("nil error written: %s", lines[2])
+	}
+}
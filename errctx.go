@@ -0,0 +1,67 @@
+package liblog
+
+import "sync"
+
+// errorContext holds the records below Error level until an error comes.
+type errorContext struct {
+	mu      sync.Mutex
+	records []heldRecord
+	next    int
+	full    bool
+}
+
+type heldRecord struct {
+	level LogLevel
+	data  []byte
+}
+
+// EnableErrorContextBuffer holds back records below Error level, Debug
+// ones included whatever the logger's level, keeping the last n of them.
+// When an Error or Fatal record is logged, the held records are written
+// first, at their own levels, then forgotten; records never followed by
+// an error are never written. This gives quiet output when all goes well
+// and the context of a failure when it does not. Held records are written
+// to stdout and the writers added with AddWriter, even when a route
+// matches them. n of 0 turns it off, dropping what is held.
+func (logger *Logger) EnableErrorContextBuffer(n int) {
+	var c *errorContext
+	if n > 0 {
+		c = &errorContext{records: make([]heldRecord, n)}
+	}
+	logger.mu.Lock()
+	logger.errorContext = c
+	logger.mu.Unlock()
+}
+
+func (logger *Logger) errorContextBuffer() *errorContext {
+	logger.mu.RLock()
+	defer logger.mu.RUnlock()
+	return logger.errorContext
+}
+
+func (c *errorContext) add(level LogLevel, record []byte) {
+	c.mu.Lock()
+	c.records[c.next] = heldRecord{level, append([]byte(nil), record...)}
+	c.next++
+	if c.next == len(c.records) {
+		c.next, c.full = 0, true
+	}
+	c.mu.Unlock()
+}
+
+// flush passes the held records to write, oldest first, and clears them.
+func (c *errorContext) flush(write func(level LogLevel, data []byte)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	records := c.records[:c.next]
+	if c.full {
+		records = append(c.records[c.next:], c.records[:c.next]...)
+	}
+	for _, r := range records {
+		write(r.level, r.data)
+	}
+	for i := range c.records {
+		c.records[i] = heldRecord{}
+	}
+	c.next, c.full = 0, false
+}
@@ -0,0 +1,42 @@
+package liblog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestErrorContextBuffer(t *testing.T) {
+	defer quiet()()
+	logger := Init("errctx", WithSynchronous())
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+	logger.SetLevel(InfoLevel)
+	logger.EnableErrorContextBuffer(2)
+
+	logger.Info("forgotten")
+	logger.Debug("step 1")
+	logger.Info("step 2")
+	if buf.Len() != 0 {
+		t.Fatalf("records written before an error: %s", buf.String())
+	}
+	logger.Error("failed")
+	logger.Info("after")
+	logger.Error("failed again")
+	logger.StopSync()
+
+	var got []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		got = append(got, line[strings.Index(line, `"level"`):strings.Index(line, `,"service"`)])
+	}
+	want := []string{
+		`"level":"DEBUG","message":"step 1"`,
+		`"level":"INFO","message":"step 2"`,
+		`"level":"ERROR","message":"failed"`,
+		`"level":"INFO","message":"after"`,
+		`"level":"ERROR","message":"failed again"`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,25 @@
+package liblog
+
+// Event logs a message with an "event" field classifying it, e.g.
+// "auth_success" or "db_timeout", so that records can be filtered by type
+// regardless of the message. The module validator set with
+// SetModuleValidator applies to eventType too; an invalid one is
+// reported as an internal error and written as is.
+func (logger *Logger) Event(eventType string, level LogLevel, format string, values ...interface{}) {
+	logger.log(level, []Field{eventField(eventType)}, format, values...)
+}
+
+func eventField(eventType string) Field {
+	if valid, err := validateModule(eventType); err != nil {
+		internalf("invalid event type %q: %v", eventType, err)
+	} else {
+		eventType = valid
+	}
+	return Field{"event", eventType}
+}
+
+func Event(eventType string, level LogLevel, format string, values ...interface{}) {
+	if singleLogger != nil {
+		singleLogger.log(level, []Field{eventField(eventType)}, format, values...)
+	}
+}
@@ -0,0 +1,28 @@
+package liblog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEvent(t *testing.T) {
+	defer quiet()()
+	logger := Init("event")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+
+	logger.With("user", "bob").Event("auth_success", InfoLevel, "logged in")
+	SetModuleValidator(NormalizeModuleName)
+	logger.Event("DB Timeout", ErrorLevel, "query took %dms", 5000)
+	SetModuleValidator(nil)
+	logger.StopSync()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if !strings.HasSuffix(lines[0], `"user":"bob","event":"auth_success"}`) {
+		t.Errorf("event field missing or before With fields: %s", lines[0])
+	}
+	if !strings.Contains(lines[1], `"level":"ERROR","message":"query took 5000ms"`) || !strings.HasSuffix(lines[1], `"event":"db_timeout"}`) {
+		t.Errorf("event type not normalized: %s", lines[1])
+	}
+}
@@ -0,0 +1,82 @@
+package liblog
+
+import (
+	"os"
+	"sync"
+)
+
+var exitFunc = struct {
+	sync.RWMutex
+	exit func(code int)
+}{exit: os.Exit}
+
+// SetExitFunc replaces os.Exit as the function called by Fatal and
+// FatalCode for every logger without its own (see Logger.SetExitFunc) and
+// for the package-level functions. nil restores os.Exit.
+func SetExitFunc(exit func(code int)) {
+	if exit == nil {
+		exit = os.Exit
+	}
+	exitFunc.Lock()
+	exitFunc.exit = exit
+	exitFunc.Unlock()
+}
+
+func globalExit(code int) {
+	exitFunc.RLock()
+	exit := exitFunc.exit
+	exitFunc.RUnlock()
+	exit(code)
+}
+
+// Fatal logs at Fatal level, stops the logger once everything logged so
+// far is written and exits with status 1.
+func (logger *Logger) Fatal(format string, values ...interface{}) {
+	logger.log(FatalLevel, nil, format, values...)
+	logger.exit(1)
+}
+
+// FatalCode is Fatal exiting with the given status.
+func (logger *Logger) FatalCode(code int, format string, values ...interface{}) {
+	logger.log(FatalLevel, nil, format, values...)
+	logger.exit(code)
+}
+
+// SetExitFunc replaces the function called by Fatal for this logger and
+// the loggers sharing its pipeline, e.g. to run cleanup first or to
+// intercept exits in tests. The logger is already stopped when it is
+// called. nil goes back to the package-level one.
+func (logger *Logger) SetExitFunc(exit func(code int)) {
+	logger.mu.Lock()
+	logger.exitFunc = exit
+	logger.mu.Unlock()
+}
+
+func (logger *Logger) exit(code int) {
+	logger.StopSync()
+	logger.mu.RLock()
+	exit := logger.exitFunc
+	logger.mu.RUnlock()
+	if exit == nil {
+		exit = globalExit
+	}
+	exit(code)
+}
+
+func Fatal(format string, values ...interface{}) {
+	if singleLogger != nil {
+		singleLogger.log(FatalLevel, nil, format, values...)
+		singleLogger.exit(1)
+		return
+	}
+	globalExit(1)
+}
+
+func FatalCode(code int, format string, values ...interface{}) {
+	if singleLogger != nil {
+		singleLogger.log(FatalLevel, nil, format, values...)
+		singleLogger.exit(code)
+		return
+	}
+	globalExit(code)
+}
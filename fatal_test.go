@@ -0,0 +1,47 @@
+package liblog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestFatalCode(t *testing.T) {
+	defer quiet()()
+	logger := Init("fatal")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+	code := -1
+	logger.SetExitFunc(func(c int) {
+		code = c
+		if !strings.Contains(buf.String(), `"level":"FATAL","message":"config missing"`) {
+			t.Errorf("message not written before exit: %s", buf.String())
+		}
+	})
+
+	logger.FatalCode(3, "config %s", "missing")
+	if code != 3 {
+		t.Errorf("exit code %d, want 3", code)
+	}
+	logger.Fatal("ignored once stopped")
+	if code != 1 || strings.Contains(buf.String(), "ignored") {
+		t.Errorf("exit code %d after Fatal, output %s", code, buf.String())
+	}
+}
+
+func TestGlobalExitFunc(t *testing.T) {
+	defer quiet()()
+	var codes []int
+	SetExitFunc(func(code int) { codes = append(codes, code) })
+	defer SetExitFunc(nil)
+
+	StopSyncSingle()
+	FatalCode(4, "no singleton")
+	InitSingleStr("fatal")
+	Fatal("singleton")
+	logger := Init("fatal")
+	logger.FatalCode(5, "logger")
+	if len(codes) != 3 || codes[0] != 4 || codes[1] != 1 || codes[2] != 5 {
+		t.Errorf("exit codes %v", codes)
+	}
+}
@@ -0,0 +1,30 @@
+package liblog
+
+import (
+	"io"
+	"os"
+)
+
+// NewFileWriterFromFD returns a writer for a file descriptor inherited
+// from the parent process, so that a process re-executing itself keeps
+// writing to the same log file without a gap and without opening it
+// again, e.g. after rotation. The parent passes the file with
+// exec.Cmd.ExtraFiles, where ExtraFiles[i] becomes descriptor 3+i in the
+// child, and both keep appending to it: open it with os.O_APPEND so that
+// their writes do not overwrite each other. name is only used in errors.
+//
+// Descriptors passed through ExtraFiles are inherited without the
+// close-on-exec flag; NewFileWriterFromFD sets it again so that the
+// descriptor does not leak into processes the child starts itself. It
+// returns nil if fd is not a valid descriptor.
+func NewFileWriterFromFD(fd uintptr, name string) io.WriteCloser {
+	file := os.NewFile(fd, name)
+	if file == nil {
+		return nil
+	}
+	if _, err := file.Stat(); err != nil {
+		return nil
+	}
+	closeOnExec(fd)
+	return file
+}
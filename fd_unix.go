@@ -0,0 +1,10 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package liblog
+
+import "syscall"
+
+func closeOnExec(fd uintptr) {
+	syscall.CloseOnExec(int(fd))
+}
@@ -0,0 +1,45 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package liblog
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestNewFileWriterFromFD(t *testing.T) {
+	dir, err := ioutil.TempDir("", "liblog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.log")
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	file.WriteString("parent\n")
+
+	// as if inherited: a descriptor of its own for the same open file
+	fd, err := syscall.Dup(int(file.Fd()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := NewFileWriterFromFD(uintptr(fd), "app.log")
+	if w == nil {
+		t.Fatal("valid descriptor rejected")
+	}
+	w.Write([]byte("child\n"))
+	w.Close()
+	if data, _ := ioutil.ReadFile(path); string(data) != "parent\nchild\n" {
+		t.Errorf("unexpected content %q", data)
+	}
+	if NewFileWriterFromFD(^uintptr(0), "bad") != nil {
+		t.Error("invalid descriptor accepted")
+	}
+}
@@ -0,0 +1,104 @@
+package liblog
+
+import (
+	"bytes"
+	"io"
+)
+
+// AddWriterFormatted adds w like AddWriter, but writes records formatted
+// by f instead of the logger's formatter, e.g. JSON to a file while stdout
+// gets ConsoleFormatter. The worker formats every message once per
+// distinct formatter, however many writers share it. Records the worker
+// only has as bytes, like those held by EnableErrorContextBuffer, are
+// written in the logger's format.
+func (logger *Logger) AddWriterFormatted(w io.Writer, f Formatter) {
+	logger.mu.Lock()
+	format := &writerFormat{f}
+	for _, other := range logger.writers {
+		if fw, ok := other.(*formattedWriter); ok && sameFormatter(fw.format.formatter, f) {
+			format = fw.format
+			break
+		}
+	}
+	logger.writers = append(logger.writers, &formattedWriter{w, format})
+	logger.mu.Unlock()
+}
+
+type formattedWriter struct {
+	io.Writer
+	format *writerFormat
+}
+
+// writerFormat is shared by the writers with equal formatters, so that the
+// worker tells them apart by pointer.
+type writerFormat struct {
+	formatter Formatter
+}
+
+// sameFormatter compares formatters, taking those that cannot be compared,
+// e.g. holding a map in an interface field, as different.
+func sameFormatter(a, b Formatter) (same bool) {
+	defer func() {
+		if recover() != nil {
+			same = false
+		}
+	}()
+	return a == b
+}
+
+func (w *formattedWriter) Close() error {
+	if closer, ok := w.Writer.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (w *formattedWriter) Reopen() error {
+	if r, ok := w.Writer.(Reopener); ok {
+		return r.Reopen()
+	}
+	return nil
+}
+
+// record is a formatted message on its way to the writers, with the
+// formats of the AddWriterFormatted writers it went to so far.
+type record struct {
+	// msg is nil when only the formatted data is known
+	msg       *LogMsg
+	data      []byte
+	opts      FormatOptions
+	formatted []formattedRecord
+}
+
+type formattedRecord struct {
+	format *writerFormat
+	buf    *bytes.Buffer
+}
+
+// bytesFor returns the record in the given format, formatting it unless a
+// writer sharing the format already did.
+func (r *record) bytesFor(format *writerFormat) ([]byte, bool) {
+	if r.msg == nil {
+		return r.data, true
+	}
+	for _, fr := range r.formatted {
+		if fr.format == format {
+			return fr.buf.Bytes(), true
+		}
+	}
+	buf := getBuffer()
+	if err := format.formatter.Format(buf, r.msg, r.opts); err != nil {
+		internalf("formatter %T failed: %v", format.formatter, err)
+		putBuffer(buf)
+		return nil, false
+	}
+	r.formatted = append(r.formatted, formattedRecord{format, buf})
+	return buf.Bytes(), true
+}
+
+func (r *record) release() {
+	for _, fr := range r.formatted {
+		putBuffer(fr.buf)
+	}
+	r.formatted = nil
+}
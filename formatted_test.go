@@ -0,0 +1,87 @@
+package liblog
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+type countingFormatter struct {
+	calls *int
+}
+
+func (f countingFormatter) Format(buf *bytes.Buffer, msg *LogMsg, opts FormatOptions) error {
+	*f.calls++
+	return LogfmtFormatter{}.Format(buf, msg, opts)
+}
+
+func TestAddWriterFormatted(t *testing.T) {
+	defer quiet()()
+	logger := Init("formats")
+	var plain, console, first, second bytes.Buffer
+	calls := 0
+	logger.AddWriter(&plain)
+	logger.AddWriterFormatted(&console, ConsoleFormatter{})
+	logger.AddWriterFormatted(&first, countingFormatter{&calls})
+	logger.AddWriterFormatted(&second, countingFormatter{&calls})
+	logger.Info("one")
+	logger.Info("two")
+	logger.StopSync()
+
+	if !strings.Contains(plain.String(), `"message":"one"`) {
+		t.Errorf("plain writer: %s", plain.String())
+	}
+	if !strings.Contains(console.String(), "INFO    formats: one") {
+		t.Errorf("console writer: %s", console.String())
+	}
+	if !strings.Contains(first.String(), `message=two`) || first.String() != second.String() {
+		t.Errorf("logfmt writers: %s / %s", first.String(), second.String())
+	}
+	if calls != 2 {
+		t.Errorf("shared formatter called %d times for 2 messages", calls)
+	}
+}
+
+// mapFormatter is comparable as a type but panics on == with a map inside.
+type mapFormatter struct {
+	extra interface{}
+}
+
+func (f mapFormatter) Format(buf *bytes.Buffer, msg *LogMsg, opts FormatOptions) error {
+	return LogfmtFormatter{}.Format(buf, msg, opts)
+}
+
+func TestAddWriterFormattedUncomparable(t *testing.T) {
+	defer quiet()()
+	logger := Init("formats")
+	var first, second bytes.Buffer
+	logger.AddWriterFormatted(&first, mapFormatter{map[string]int{}})
+	logger.AddWriterFormatted(&second, mapFormatter{map[string]int{}})
+	logger.Info("one")
+	logger.StopSync()
+
+	if !strings.Contains(first.String(), "message=one") || first.String() != second.String() {
+		t.Errorf("writers: %q / %q", first.String(), second.String())
+	}
+}
+
+func TestAddWriterFormattedBroken(t *testing.T) {
+	defer quiet()()
+	logger := Init("formats", WithSynchronous(), WithErrorOutput(ioutil.Discard))
+	logger.SetWriteFailurePolicy(SkipBrokenWriters)
+	w := new(shortWriter)
+	logger.AddWriterFormatted(w, LogfmtFormatter{})
+	if writers := logger.Writers(); len(writers) != 1 || writers[0] != w {
+		t.Fatalf("Writers returned %v", writers)
+	}
+	logger.Info("first")
+	logger.Info("second")
+	logger.ResetWriter(w)
+	logger.Info("third")
+	logger.StopSync()
+
+	if strings.Contains(w.buf.String(), "second") || !strings.Contains(w.buf.String(), "message=third") {
+		t.Errorf("unexpected output: %q", w.buf.String())
+	}
+}
@@ -0,0 +1,216 @@
+package liblog
+
+import (
+	"bytes"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Formatter turns a message into the bytes of one record, line terminator
+// included, appending them to buf.
+type Formatter interface {
+	Format(buf *bytes.Buffer, msg *LogMsg, opts FormatOptions) error
+}
+
+// FormatOptions holds the logger settings formatters are expected to honour.
+type FormatOptions struct {
+	// CallerField asks for the source location as a single "file.go:42"
+	// caller field
+	CallerField bool
+	// NestedSource asks for the source location as a "source" object with
+	// file, line and function keys; it takes precedence over CallerField
+	NestedSource bool
+	// LevelNumber adds the numeric value of the level as "levelno"
+	LevelNumber bool
+	// OmitEmptyMessage leaves the message key out when the message is
+	// empty instead of writing ""
+	OmitEmptyMessage bool
+	// TimeFormat is how the timestamp is written
+	TimeFormat TimeFormat
+	// Escape is the escape table of the JSON format, nil for the default
+	Escape *EscapeTable
+	// Severity maps levels for backends, see SetSeverityMapper
+	Severity func(level LogLevel) (label string, number int)
+	// LineTerminator ends every record, see Terminator
+	LineTerminator string
+	// lineTerminatorSet tells an empty LineTerminator from the default
+	lineTerminatorSet bool
+}
+
+// Terminator returns the string formatters end records with: "\n" unless
+// SetLineTerminator chose another one.
+func (opts FormatOptions) Terminator() string {
+	if !opts.lineTerminatorSet && opts.LineTerminator == "" {
+		return "\n"
+	}
+	return opts.LineTerminator
+}
+
+// TimeFormat selects how timestamps are written by the JSON and logfmt
+// formats. Epoch variants are written as bare numbers.
+type TimeFormat int
+
+const (
+	// TimeRFC3339Nano is the default: RFC 3339 with up to nanoseconds
+	TimeRFC3339Nano TimeFormat = iota
+	TimeRFC3339
+	// TimeEpoch is in seconds since the Unix epoch
+	TimeEpoch
+	TimeEpochMilli
+	TimeEpochNano
+	// TimeRFC3339Milli and TimeRFC3339Micro always write 3 and 6 digits
+	TimeRFC3339Milli
+	TimeRFC3339Micro
+)
+
+var timeFormatNames = map[string]TimeFormat{
+	"rfc3339nano":  TimeRFC3339Nano,
+	"rfc3339":      TimeRFC3339,
+	"epoch":        TimeEpoch,
+	"epochmilli":   TimeEpochMilli,
+	"epochnano":    TimeEpochNano,
+	"rfc3339milli": TimeRFC3339Milli,
+	"rfc3339micro": TimeRFC3339Micro,
+}
+
+// appendTime appends t in format f and reports whether it is a number,
+// which JSON does not quote.
+func appendTime(dst []byte, t time.Time, f TimeFormat) ([]byte, bool) {
+	switch f {
+	case TimeRFC3339:
+		return t.AppendFormat(dst, time.RFC3339), false
+	case TimeRFC3339Milli:
+		return t.AppendFormat(dst, "2006-01-02T15:04:05.000Z07:00"), false
+	case TimeRFC3339Micro:
+		return t.AppendFormat(dst, "2006-01-02T15:04:05.000000Z07:00"), false
+	case TimeEpoch:
+		return strconv.AppendInt(dst, t.Unix(), 10), true
+	case TimeEpochMilli:
+		return strconv.AppendInt(dst, t.UnixNano()/int64(time.Millisecond), 10), true
+	case TimeEpochNano:
+		return strconv.AppendInt(dst, t.UnixNano(), 10), true
+	}
+	return t.AppendFormat(dst, time.RFC3339Nano), false
+}
+
+// SetFormatter changes the output format of the logger, JSONFormatter by
+// default. It is safe to call while logging: queued messages are written
+// with the formatter in place when the worker takes them from the queue,
+// so messages logged just before the call may use the new one.
+func (logger *Logger) SetFormatter(formatter Formatter) {
+	logger.mu.Lock()
+	logger.formatter = formatter
+	logger.mu.Unlock()
+}
+
+func (logger *Logger) currentFormatter() Formatter {
+	logger.mu.RLock()
+	defer logger.mu.RUnlock()
+	if logger.formatter == nil {
+		return JSONFormatter{}
+	}
+	return logger.formatter
+}
+
+// SetCallerSingleField writes the source location as one "caller" field,
+// e.g. "caller":"handler.go:42", instead of src_file and src_line.
+func (logger *Logger) SetCallerSingleField(enable bool) {
+	logger.mu.Lock()
+	logger.format.CallerField = enable
+	logger.mu.Unlock()
+}
+
+// SetOmitEmptyMessage leaves the message key out of records with an empty
+// message, e.g. InfoKV("", "event", "login"). The console format, meant
+// for people, is not affected.
+func (logger *Logger) SetOmitEmptyMessage(enable bool) {
+	logger.mu.Lock()
+	logger.format.OmitEmptyMessage = enable
+	logger.mu.Unlock()
+}
+
+// SetEmitLevelNumber adds the numeric value of the level, e.g. 3 for
+// Error, as a "levelno" field after "level", for consumers filtering on
+// numbers.
+func (logger *Logger) SetEmitLevelNumber(enable bool) {
+	logger.mu.Lock()
+	logger.format.LevelNumber = enable
+	logger.mu.Unlock()
+}
+
+// SetTimeFormat changes how the JSON and logfmt formats write timestamps.
+// The console format, meant for people, is not affected. The LOGTIME
+// environment variable sets it at Init. Timestamps are always taken with
+// the full precision of the clock when the message is logged, not when
+// it is written: the format only decides how much of it is shown.
+func (logger *Logger) SetTimeFormat(format TimeFormat) {
+	logger.mu.Lock()
+	logger.format.TimeFormat = format
+	logger.mu.Unlock()
+}
+
+// WithTimeFormat returns a logger sharing the pipeline of this one whose
+// messages are written with format instead of the one set with
+// SetTimeFormat, e.g. TimeRFC3339Nano for latency-sensitive events when
+// the default is TimeRFC3339.
+func (logger *Logger) WithTimeFormat(format TimeFormat) *Logger {
+	child := *logger
+	child.timeFormat = &format
+	return &child
+}
+
+// SetLineTerminator changes what ends every record written by the JSON,
+// logfmt and console formats: "\r\n" for Windows consumers, "\x00" for
+// NUL-delimited streams or "" for writers framing records themselves,
+// e.g. with a length prefix. The default is "\n". A terminator that can
+// occur inside records, like "}", makes the stream ambiguous; JSON
+// records never contain raw control characters, so any of them is safe.
+func (logger *Logger) SetLineTerminator(terminator string) {
+	logger.mu.Lock()
+	logger.format.LineTerminator = terminator
+	logger.format.lineTerminatorSet = true
+	logger.mu.Unlock()
+}
+
+func (logger *Logger) formatOptions() FormatOptions {
+	logger.mu.RLock()
+	defer logger.mu.RUnlock()
+	return logger.format
+}
+
+// SetPostFormatHook calls hook with every formatted record, on the worker
+// goroutine, before it is written. formatted is only valid during the
+// call: copy it to keep it. nil removes the hook.
+func (logger *Logger) SetPostFormatHook(hook func(level LogLevel, formatted []byte)) {
+	logger.mu.Lock()
+	logger.hook = hook
+	logger.mu.Unlock()
+}
+
+func (logger *Logger) postFormatHook() func(level LogLevel, formatted []byte) {
+	logger.mu.RLock()
+	defer logger.mu.RUnlock()
+	return logger.hook
+}
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		buf := new(bytes.Buffer)
+		buf.Grow(256)
+		return buf
+	},
+}
+
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	// keep huge messages from pinning memory in the pool
+	if buf.Cap() > 64<<10 {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
@@ -0,0 +1,32 @@
+package liblog
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+)
+
+// SetCaptureGoroutineID adds the id of the logging goroutine as a "goid"
+// field. Go has no public API for it, so the id is parsed out of
+// runtime.Stack on every call: use this for debugging only.
+func (logger *Logger) SetCaptureGoroutineID(enable bool) {
+	var flag int32
+	if enable {
+		flag = 1
+	}
+	atomic.StoreInt32(&logger.captureGoid, flag)
+}
+
+var goroutinePrefix = []byte("goroutine ")
+
+func goroutineID() uint64 {
+	var buf [64]byte
+	stack := buf[:runtime.Stack(buf[:], false)]
+	stack = bytes.TrimPrefix(stack, goroutinePrefix)
+	if i := bytes.IndexByte(stack, ' '); i > 0 {
+		stack = stack[:i]
+	}
+	id, _ := strconv.ParseUint(string(stack), 10, 64)
+	return id
+}
@@ -0,0 +1,33 @@
+package liblog
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCaptureGoroutineID(t *testing.T) {
+	defer quiet()()
+	logger := Init("goid")
+	defer logger.StopSync()
+
+	var msg map[string]interface{}
+	json.Unmarshal(logger.Render(InfoLevel, "off"), &msg)
+	if _, ok := msg["goid"]; ok {
+		t.Errorf("goid written while disabled: %v", msg)
+	}
+
+	logger.SetCaptureGoroutineID(true)
+	ids := make(chan float64, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			var msg map[string]interface{}
+			json.Unmarshal(logger.Render(InfoLevel, "on"), &msg)
+			id, _ := msg["goid"].(float64)
+			ids <- id
+		}()
+	}
+	first, second := <-ids, <-ids
+	if first == 0 || second == 0 || first == second {
+		t.Errorf("goroutine ids %v and %v", first, second)
+	}
+}
@@ -0,0 +1,62 @@
+package liblog
+
+import "bytes"
+
+// Fields is a group of fields written as a nested object, e.g. by a
+// logger returned from Group.
+type Fields []Field
+
+func (fields Fields) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	writeJSONObject(&buf, &defaultEscape, fields)
+	return buf.Bytes(), nil
+}
+
+func writeJSONObject(buf *bytes.Buffer, escape *EscapeTable, fields Fields) {
+	buf.WriteByte('{')
+	for i, field := range fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		writeJSONString(buf, escape, field.Key)
+		buf.WriteByte(':')
+		writeJSONValue(buf, escape, field.Value)
+	}
+	buf.WriteByte('}')
+}
+
+// Group returns a logger writing the fields added afterwards, with With or
+// with a log call, in an object under name. Groups nest, and the fields
+// of successive With calls in the same group share one object:
+//
+//	logger.Group("http").With("method", "GET").InfoKV("done", "status", 200)
+//
+// writes "http":{"method":"GET","status":200}. The group is only written
+// once it has fields. Like any repeated key, its name is written once per
+// level: of a field and a group with the same name, the last one wins, at
+// the position of the first.
+func (logger *Logger) Group(name string) *Logger {
+	child := *logger
+	child.groups = append(logger.groups[:len(logger.groups):len(logger.groups)], name)
+	return &child
+}
+
+// nest adds fields inside the group at path, reusing the last group with
+// that name. fields is not modified.
+func nest(fields []Field, path []string, add []Field) []Field {
+	if len(path) == 0 {
+		out := make([]Field, 0, len(fields)+len(add))
+		out = append(out, fields...)
+		return append(out, add...)
+	}
+	for i := len(fields) - 1; i >= 0; i-- {
+		if group, ok := fields[i].Value.(Fields); ok && fields[i].Key == path[0] {
+			out := append([]Field(nil), fields...)
+			out[i].Value = Fields(nest(group, path[1:], add))
+			return out
+		}
+	}
+	out := make([]Field, 0, len(fields)+1)
+	out = append(out, fields...)
+	return append(out, Field{path[0], Fields(nest(nil, path[1:], add))})
+}
@@ -0,0 +1,42 @@
+package liblog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestGroup(t *testing.T) {
+	defer quiet()()
+	logger := Init("group")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+
+	http := logger.With("request", 1).Group("http").With("method", "GET")
+	http.InfoKV("done", "status", 200)
+	http.Group("tls").With("version", "1.3").Info("nested")
+	logger.With("http", "plain").Group("http").InfoKV("collision", "a", 1)
+	logger.Group("empty").Info("no fields")
+	logger.StopSync()
+
+	want := []string{
+		`"request":1,"http":{"method":"GET","status":200}}`,
+		`"request":1,"http":{"method":"GET","tls":{"version":"1.3"}}}`,
+		`,"http":{"a":1}}`,
+		`}`,
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines: %s", len(lines), buf.String())
+	}
+	for i, line := range lines {
+		if !strings.HasSuffix(line, want[i]) {
+			t.Errorf("line %d: %s, want suffix %s", i, line, want[i])
+		}
+	}
+	// the group replaces the field of the same name, and an empty group is
+	// not written
+	if strings.Contains(lines[2], `"plain"`) || strings.Contains(lines[3], `"empty"`) {
+		t.Errorf("unexpected fields: %s", strings.Join(lines[2:], "\n"))
+	}
+}
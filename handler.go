@@ -0,0 +1,46 @@
+package liblog
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+type levelPayload struct {
+	Level string `json:"level,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// LevelHandler returns an HTTP handler reporting the current level on GET
+// and changing it on PUT or POST with a body like {"level":"debug"}.
+func (logger *Logger) LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveLevel(w, r, logger.GetLevel, logger.SetLevel)
+	})
+}
+
+func serveLevel(w http.ResponseWriter, r *http.Request, get func() LogLevel, set func(LogLevel)) {
+	w.Header().Set("Content-Type", "application/json")
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		var req levelPayload
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(levelPayload{Error: err.Error()})
+			return
+		}
+		level, err := ParseLevel(req.Level)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(levelPayload{Error: err.Error()})
+			return
+		}
+		set(level)
+	default:
+		w.Header().Set("Allow", "GET, PUT, POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(levelPayload{Error: "method not allowed"})
+		return
+	}
+	json.NewEncoder(w).Encode(levelPayload{Level: get().String()})
+}
@@ -0,0 +1,39 @@
+package liblog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLevelHandler(t *testing.T) {
+	defer quiet()()
+	logger := Init("handler")
+	defer logger.StopSync()
+	handler := logger.LevelHandler()
+
+	cases := []struct {
+		method, body string
+		code         int
+		response     string
+	}{
+		{"GET", "", http.StatusOK, `{"level":"INFO"}`},
+		{"PUT", `{"level":"debug"}`, http.StatusOK, `{"level":"DEBUG"}`},
+		{"POST", `{"level":"warn"}`, http.StatusOK, `{"level":"WARNING"}`},
+		{"PUT", `{"level":"loud"}`, http.StatusBadRequest, `{"error":"liblog: unknown log level \"loud\""}`},
+		{"POST", `level=debug`, http.StatusBadRequest, ``},
+		{"DELETE", "", http.StatusMethodNotAllowed, `{"error":"method not allowed"}`},
+		{"GET", "", http.StatusOK, `{"level":"WARNING"}`},
+	}
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(c.method, "/loglevel", strings.NewReader(c.body)))
+		if rec.Code != c.code {
+			t.Errorf("%s %s: code %d, want %d", c.method, c.body, rec.Code, c.code)
+		}
+		if c.response != "" && strings.TrimSpace(rec.Body.String()) != c.response {
+			t.Errorf("%s %s: body %s, want %s", c.method, c.body, rec.Body.String(), c.response)
+		}
+	}
+}
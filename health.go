@@ -0,0 +1,91 @@
+package liblog
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Default thresholds of Healthy, see SetHealthThreshold.
+const (
+	DefaultHealthDrops  = 1000
+	DefaultHealthWindow = time.Minute
+)
+
+// health counts dropped messages in fixed windows.
+type health struct {
+	mu       sync.Mutex
+	maxDrops uint64
+	window   time.Duration
+	start    time.Time
+	current  uint64
+	previous uint64
+}
+
+// SetHealthThreshold makes Healthy report false once more than drops
+// messages were dropped within window. A window <= 0 restores the
+// defaults, DefaultHealthDrops per DefaultHealthWindow.
+func (logger *Logger) SetHealthThreshold(drops uint64, window time.Duration) {
+	h := &logger.health
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if window <= 0 {
+		drops, window = 0, 0
+	}
+	h.maxDrops, h.window = drops, window
+	h.start, h.current, h.previous = time.Time{}, 0, 0
+}
+
+func (h *health) limits() (uint64, time.Duration) {
+	if h.window <= 0 {
+		return DefaultHealthDrops, DefaultHealthWindow
+	}
+	return h.maxDrops, h.window
+}
+
+// advance moves to the window holding now. h.mu must be held.
+func (h *health) advance(now time.Time) {
+	_, window := h.limits()
+	switch elapsed := now.Sub(h.start); {
+	case elapsed < window:
+	case elapsed < 2*window:
+		h.start = h.start.Add(window)
+		h.previous, h.current = h.current, 0
+	default:
+		h.start = now
+		h.previous, h.current = 0, 0
+	}
+}
+
+func (h *health) dropped() {
+	h.mu.Lock()
+	h.advance(time.Now())
+	h.current++
+	h.mu.Unlock()
+}
+
+func (h *health) overThreshold() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.advance(time.Now())
+	maxDrops, _ := h.limits()
+	return h.current > maxDrops || h.previous > maxDrops
+}
+
+// Healthy reports whether the logger can be relied on: it has not been
+// stopped, its worker is running and it has not dropped more messages
+// than allowed by SetHealthThreshold in the current or the previous
+// window. It is meant for readiness probes, together with QueueDepth and
+// Dropped as metrics.
+func (logger *Logger) Healthy() bool {
+	logger.closeMu.RLock()
+	closed := logger.closed
+	logger.closeMu.RUnlock()
+	if closed {
+		return false
+	}
+	if !logger.synchronous && atomic.LoadInt32(&logger.running) == 0 {
+		return false
+	}
+	return !logger.health.overThreshold()
+}
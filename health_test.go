@@ -0,0 +1,41 @@
+package liblog
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestHealthy(t *testing.T) {
+	defer quiet()()
+	os.Setenv("LOG_QUEUE_LEN", "1")
+	defer os.Unsetenv("LOG_QUEUE_LEN")
+
+	logger := Init("health")
+	logger.SetSilentDrop(true)
+	logger.SetHealthThreshold(10, time.Hour)
+	release := make(chan struct{})
+	logger.AddWriter(blockingWriter{release})
+	if !logger.Healthy() {
+		t.Error("new logger not healthy")
+	}
+
+	for i := 0; i < 5; i++ {
+		logger.Info("message %d", i)
+	}
+	if !logger.Healthy() {
+		t.Errorf("unhealthy after %d drops", logger.Dropped())
+	}
+	for i := 0; i < 20; i++ {
+		logger.Info("message %d", i)
+	}
+	if logger.Healthy() {
+		t.Errorf("healthy after %d drops", logger.Dropped())
+	}
+	close(release)
+	logger.StopSync()
+	logger.SetHealthThreshold(0, 0)
+	if logger.Healthy() {
+		t.Error("stopped logger healthy")
+	}
+}
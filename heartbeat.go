@@ -0,0 +1,61 @@
+package liblog
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// EnableHeartbeat logs an Info record with the "liblog_stats" event every
+// d, carrying the number of messages written per level since the logger
+// started, the number dropped and the current queue depth. Calling it
+// again changes the interval; d <= 0 stops it. The heartbeat stops with
+// the logger.
+func (logger *Logger) EnableHeartbeat(d time.Duration) {
+	logger.closeMu.Lock()
+	defer logger.closeMu.Unlock()
+	if logger.heartbeat != nil {
+		close(logger.heartbeat)
+		logger.heartbeat = nil
+	}
+	if d <= 0 || logger.closed {
+		return
+	}
+	stop := make(chan struct{})
+	logger.heartbeat = stop
+	go logger.beat(d, stop)
+}
+
+func (logger *Logger) beat(d time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(d)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			logger.logDepth(0, InfoLevel, logger.stats(), "logger stats")
+		}
+	}
+}
+
+func (logger *Logger) stats() []Field {
+	emitted := make(Fields, 0, len(logger.emitted))
+	for level := range logger.emitted {
+		name := strings.ToLower(LogLevel(level).String())
+		emitted = append(emitted, Field{name, atomic.LoadUint64(&logger.emitted[level])})
+	}
+	return []Field{
+		{"event", "liblog_stats"},
+		{"emitted", emitted},
+		{"dropped", logger.Dropped()},
+		{"queue_depth", logger.QueueDepth()},
+	}
+}
+
+// countEmitted counts a message written at level.
+func (logger *Logger) countEmitted(level LogLevel) {
+	if level >= 0 && int(level) < len(logger.emitted) {
+		atomic.AddUint64(&logger.emitted[level], 1)
+	}
+}
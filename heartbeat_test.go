@@ -0,0 +1,33 @@
+package liblog
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHeartbeat(t *testing.T) {
+	defer quiet()()
+	logger := Init("heartbeat")
+	var capture syncBuffer
+	logger.AddWriter(&capture)
+
+	logger.Warning("before")
+	logger.EnableHeartbeat(10 * time.Millisecond)
+	waitFor(t, func() bool { return strings.Contains(capture.String(), "liblog_stats") })
+	goroutines := runtime.NumGoroutine()
+	logger.StopSync()
+
+	out := capture.String()
+	want := `"message":"logger stats","service":"heartbeat","event":"liblog_stats","emitted":{"debug":0,"info":0,"warning":1,`
+	if !strings.Contains(out, want) {
+		t.Errorf("no stats record in %s", out)
+	}
+	for i := 0; i < 100 && runtime.NumGoroutine() >= goroutines; i++ {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n >= goroutines {
+		t.Errorf("heartbeat goroutine still running: %d goroutines, had %d", n, goroutines)
+	}
+}
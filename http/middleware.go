@@ -0,0 +1,128 @@
+// Package http provides net/http middleware writing access logs through a
+// liblog logger.
+package http
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"net"
+	nethttp "net/http"
+	"time"
+
+	"github.com/wimark/liblog"
+)
+
+// RequestIDHeader is the header a request ID is taken from and returned in.
+const RequestIDHeader = "X-Request-ID"
+
+// Middleware logs every request with the fields of liblog.RequestFields
+// plus the number of body bytes written. The request ID, taken from the
+// X-Request-ID header or generated, is returned in that header and stored
+// in the request context with liblog.WithRequestID, so the *Ctx log calls
+// of downstream handlers carry it too. A panicking handler is logged at
+// Error level and answered with 500 if nothing was written yet.
+func Middleware(logger *liblog.Logger) func(nethttp.Handler) nethttp.Handler {
+	return func(next nethttp.Handler) nethttp.Handler {
+		return nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+			start := time.Now()
+			id := r.Header.Get(RequestIDHeader)
+			if id == "" {
+				id = newRequestID()
+			}
+			w.Header().Set(RequestIDHeader, id)
+			ctx := liblog.WithRequestID(r.Context(), id)
+			r = r.WithContext(ctx)
+			rw := &responseWriter{ResponseWriter: w}
+
+			defer func() {
+				if p := recover(); p != nil {
+					if p == nethttp.ErrAbortHandler {
+						panic(p)
+					}
+					logger.ErrorCtx(ctx, "panic serving %s %s: %v", r.Method, r.URL.Path, p)
+					if rw.status == 0 {
+						rw.WriteHeader(nethttp.StatusInternalServerError)
+					}
+				}
+				status := rw.status
+				if status == 0 {
+					status = nethttp.StatusOK
+				}
+				fields := liblog.RequestFields(r, status, time.Since(start))
+				keyvals := make([]interface{}, 0, len(fields)+1)
+				for _, field := range fields {
+					keyvals = append(keyvals, field)
+				}
+				keyvals = append(keyvals, liblog.Any("bytes", rw.written))
+				logger.With(keyvals...).InfoCtx(ctx, "%s %s %d", r.Method, r.URL.Path, status)
+			}()
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+func newRequestID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// still unique enough to correlate the logs of one request
+		binary.BigEndian.PutUint64(b[:], uint64(time.Now().UnixNano()))
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// responseWriter records the status and the number of bytes written. It
+// forwards http.Flusher, http.Hijacker and http.Pusher, and Unwrap gives
+// http.ResponseController the underlying writer.
+type responseWriter struct {
+	nethttp.ResponseWriter
+	status  int
+	written int64
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = nethttp.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.written += int64(n)
+	return n, err
+}
+
+func (w *responseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(nethttp.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(nethttp.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("http: response writer does not support hijacking")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err == nil && w.status == 0 {
+		w.status = nethttp.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+func (w *responseWriter) Push(target string, opts *nethttp.PushOptions) error {
+	if pusher, ok := w.ResponseWriter.(nethttp.Pusher); ok {
+		return pusher.Push(target, opts)
+	}
+	return nethttp.ErrNotSupported
+}
+
+func (w *responseWriter) Unwrap() nethttp.ResponseWriter {
+	return w.ResponseWriter
+}
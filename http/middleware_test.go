@@ -0,0 +1,104 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	nethttp "net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/wimark/liblog"
+)
+
+func TestMiddleware(t *testing.T) {
+	null, _ := os.Open(os.DevNull)
+	stdout := os.Stdout
+	os.Stdout = null
+	defer func() {
+		os.Stdout = stdout
+		null.Close()
+	}()
+
+	logger := liblog.Init("http", liblog.WithSynchronous())
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+	handler := Middleware(logger)(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if r.URL.Path == "/panic" {
+			panic("boom")
+		}
+		logger.InfoCtx(r.Context(), "handling")
+		w.WriteHeader(nethttp.StatusCreated)
+		w.Write([]byte("hello"))
+	}))
+
+	r := httptest.NewRequest("POST", "/items", nil)
+	r.Header.Set(RequestIDHeader, "abc")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+	if rec.Header().Get(RequestIDHeader) != "abc" {
+		t.Errorf("request id not returned: %v", rec.Header())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/panic", nil))
+	if rec.Code != 500 || len(rec.Header().Get(RequestIDHeader)) != 16 {
+		t.Errorf("panic answered with %d, headers %v", rec.Code, rec.Header())
+	}
+	logger.StopSync()
+
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]interface{}
+		json.Unmarshal([]byte(line), &record)
+		records = append(records, record)
+	}
+	if len(records) != 4 {
+		t.Fatalf("got %d records: %s", len(records), buf.String())
+	}
+	if records[0]["message"] != "handling" || records[0]["request_id"] != "abc" {
+		t.Errorf("handler record %v", records[0])
+	}
+	access := records[1]
+	if access["message"] != "POST /items 201" || access["status"] != 201.0 || access["bytes"] != 5.0 || access["request_id"] != "abc" {
+		t.Errorf("access record %v", access)
+	}
+	if records[2]["level"] != "ERROR" || records[3]["status"] != 500.0 {
+		t.Errorf("panic records %v %v", records[2], records[3])
+	}
+}
+
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+}
+
+func (r *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	r.hijacked = true
+	return nil, nil, nil
+}
+
+func TestMiddlewareForwards(t *testing.T) {
+	logger := liblog.Init("http", liblog.WithSynchronous(), liblog.WithOutput(ioutil.Discard))
+	defer logger.StopSync()
+	handler := Middleware(logger)(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if _, _, err := w.(nethttp.Hijacker).Hijack(); err != nil {
+			t.Errorf("hijack: %v", err)
+		}
+		if err := w.(nethttp.Pusher).Push("/style.css", nil); err != nethttp.ErrNotSupported {
+			t.Errorf("push on a recorder: %v", err)
+		}
+		if _, ok := w.(interface{ Unwrap() nethttp.ResponseWriter }); !ok {
+			t.Error("no Unwrap for http.ResponseController")
+		}
+	}))
+	rec := &hijackRecorder{ResponseRecorder: httptest.NewRecorder()}
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/ws", nil))
+	if !rec.hijacked {
+		t.Error("Hijack not forwarded")
+	}
+}
@@ -0,0 +1,34 @@
+package liblog
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"sync"
+)
+
+var internalOutput struct {
+	sync.RWMutex
+	w io.Writer
+}
+
+// SetInternalErrorWriter sends the diagnostics of liblog itself (dropped
+// messages, failing writers and so on) to w, one line each, instead of the
+// standard library logger. nil restores the standard logger.
+func SetInternalErrorWriter(w io.Writer) {
+	internalOutput.Lock()
+	internalOutput.w = w
+	internalOutput.Unlock()
+}
+
+// internalf reports a problem of liblog itself.
+func internalf(format string, values ...interface{}) {
+	internalOutput.RLock()
+	w := internalOutput.w
+	internalOutput.RUnlock()
+	if w == nil {
+		log.Printf("liblog: "+format, values...)
+		return
+	}
+	fmt.Fprintf(w, "liblog: "+format+"\n", values...)
+}
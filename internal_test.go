@@ -0,0 +1,23 @@
+package liblog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestInternalErrorWriter(t *testing.T) {
+	defer quiet()()
+	var diagnostics bytes.Buffer
+	SetInternalErrorWriter(&diagnostics)
+	defer SetInternalErrorWriter(nil)
+
+	logger := Init("internal", WithSynchronous())
+	logger.AddWriter(panickingWriter{})
+	logger.Info("boom")
+	logger.StopSync()
+
+	if got := diagnostics.String(); !strings.HasPrefix(got, "liblog: writer liblog.panickingWriter panicked: ") || !strings.HasSuffix(got, "\n") {
+		t.Errorf("unexpected diagnostics: %q", got)
+	}
+}
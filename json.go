@@ -0,0 +1,232 @@
+package liblog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+)
+
+const hexDigits = "0123456789abcdef"
+
+// EscapeTable marks the ASCII bytes the JSON format escapes in strings it
+// writes: keys, the message and other string values. Bytes with a short
+// escape (\", \\, \n, \r, \t) use it, others are written as \u00XX.
+// SetEscapeTable marks control characters, '"' and '\\' in any table, for
+// the output to be valid JSON. Non-ASCII text is not affected, nor are
+// values encoded with encoding/json.
+type EscapeTable [utf8.RuneSelf]bool
+
+// defaultEscape escapes what JSON requires and, like encoding/json, HTML
+// characters.
+var defaultEscape = func() (table EscapeTable) {
+	table.markRequired()
+	for _, b := range `<>&` {
+		table[b] = true
+	}
+	return table
+}()
+
+// markRequired marks the bytes JSON does not allow unescaped in strings.
+func (table *EscapeTable) markRequired() {
+	for b := 0; b < ' '; b++ {
+		table[b] = true
+	}
+	table['"'], table['\\'] = true, true
+}
+
+// DefaultEscapeTable returns a copy of the table used unless
+// SetEscapeTable says otherwise, to build a custom table from.
+func DefaultEscapeTable() EscapeTable {
+	return defaultEscape
+}
+
+// SetEscapeTable makes the JSON format escape the bytes marked in table,
+// e.g. '=' for consumers that split on it or 0x7f for picky parsers, and
+// the bytes JSON requires to escape even if the table does not mark them.
+// nil restores DefaultEscapeTable.
+func (logger *Logger) SetEscapeTable(table *EscapeTable) {
+	if table != nil {
+		copied := *table
+		copied.markRequired()
+		table = &copied
+	}
+	logger.mu.Lock()
+	logger.format.Escape = table
+	logger.mu.Unlock()
+}
+
+// JSONFormatter writes every message as a JSON object on its own line.
+// It is the default formatter.
+type JSONFormatter struct{}
+
+func (JSONFormatter) Format(buf *bytes.Buffer, msg *LogMsg, opts FormatOptions) error {
+	writeJSON(buf, msg, opts)
+	buf.WriteString(opts.Terminator())
+	return nil
+}
+
+func (msg LogMsg) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	writeJSON(&buf, &msg, FormatOptions{})
+	return buf.Bytes(), nil
+}
+
+// writeJSON encodes msg the same way encoding/json does, with fields added
+// at the top level.
+func writeJSON(buf *bytes.Buffer, msg *LogMsg, opts FormatOptions) {
+	escape := opts.Escape
+	if escape == nil {
+		escape = &defaultEscape
+	}
+	var scratch [64]byte
+	buf.WriteString(`{"timestamp":`)
+	if ts, number := appendTime(scratch[:0], msg.Timestamp, opts.TimeFormat); number {
+		buf.Write(ts)
+	} else {
+		buf.WriteByte('"')
+		buf.Write(ts)
+		buf.WriteByte('"')
+	}
+	buf.WriteString(`,"level":`)
+	label, number, severity := opts.SeverityOf(msg.Level)
+	writeJSONString(buf, escape, label)
+	if severity {
+		buf.WriteString(`,"severity":`)
+		buf.WriteString(strconv.Itoa(number))
+	}
+	if opts.LevelNumber {
+		buf.WriteString(`,"levelno":`)
+		buf.WriteString(strconv.Itoa(int(msg.Level)))
+	}
+	if msg.Message != "" || !opts.OmitEmptyMessage {
+		buf.WriteString(`,"message":`)
+		writeJSONString(buf, escape, msg.Message)
+	}
+	if msg.Module != "" {
+		buf.WriteString(`,"service":`)
+		writeJSONString(buf, escape, msg.Module)
+	}
+	if msg.ModuleId != "" {
+		buf.WriteString(`,"service_id":`)
+		writeJSONString(buf, escape, msg.ModuleId)
+	}
+	if msg.Logger != "" {
+		buf.WriteString(`,"logger":`)
+		writeJSONString(buf, escape, msg.Logger)
+	}
+	if opts.NestedSource {
+		if msg.SrcFile != "" {
+			buf.WriteString(`,"source":{"file":`)
+			writeJSONString(buf, escape, msg.SrcFile)
+			buf.WriteString(`,"line":`)
+			buf.Write(strconv.AppendInt(scratch[:0], int64(msg.SrcLine), 10))
+			if msg.SrcFunc != "" {
+				buf.WriteString(`,"function":`)
+				writeJSONString(buf, escape, msg.SrcFunc)
+			}
+			buf.WriteByte('}')
+		}
+	} else if opts.CallerField {
+		if msg.SrcFile != "" {
+			buf.WriteString(`,"caller":`)
+			writeJSONString(buf, escape, msg.SrcFile+":"+strconv.Itoa(msg.SrcLine))
+		}
+	} else {
+		if msg.SrcFile != "" {
+			buf.WriteString(`,"src_file":`)
+			writeJSONString(buf, escape, msg.SrcFile)
+		}
+		if msg.SrcLine != 0 {
+			buf.WriteString(`,"src_line":`)
+			buf.Write(strconv.AppendInt(scratch[:0], int64(msg.SrcLine), 10))
+		}
+		if msg.SrcFunc != "" {
+			buf.WriteString(`,"src_func":`)
+			writeJSONString(buf, escape, msg.SrcFunc)
+		}
+	}
+	if msg.GoroutineId != 0 {
+		buf.WriteString(`,"goid":`)
+		buf.Write(strconv.AppendUint(scratch[:0], msg.GoroutineId, 10))
+	}
+	for _, field := range msg.Fields {
+		buf.WriteByte(',')
+		writeJSONString(buf, escape, field.Key)
+		buf.WriteByte(':')
+		writeJSONValue(buf, escape, field.Value)
+	}
+	buf.WriteByte('}')
+}
+
+func writeJSONValue(buf *bytes.Buffer, escape *EscapeTable, value interface{}) {
+	switch v := value.(type) {
+	case string:
+		writeJSONString(buf, escape, v)
+		return
+	case Fields:
+		writeJSONObject(buf, escape, v)
+		return
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		writeJSONString(buf, escape, fmt.Sprint(value))
+		return
+	}
+	buf.Write(data)
+}
+
+// writeJSONString copies runs of plain ASCII in one go and only decodes
+// runes around bytes that need care.
+func writeJSONString(buf *bytes.Buffer, escape *EscapeTable, s string) {
+	buf.WriteByte('"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if !escape[b] {
+				i++
+				continue
+			}
+			buf.WriteString(s[start:i])
+			switch b {
+			case '\\', '"':
+				buf.WriteByte('\\')
+				buf.WriteByte(b)
+			case '\n':
+				buf.WriteString(`\n`)
+			case '\r':
+				buf.WriteString(`\r`)
+			case '\t':
+				buf.WriteString(`\t`)
+			default:
+				buf.WriteString(`\u00`)
+				buf.WriteByte(hexDigits[b>>4])
+				buf.WriteByte(hexDigits[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf.WriteString(s[start:i])
+			buf.WriteString("\ufffd")
+			i += size
+			start = i
+			continue
+		}
+		// U+2028 and U+2029 are valid JSON but break JavaScript parsers
+		if r == '\u2028' || r == '\u2029' {
+			buf.WriteString(s[start:i])
+			buf.WriteString(`\u202`)
+			buf.WriteByte(hexDigits[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	buf.WriteString(s[start:])
+	buf.WriteByte('"')
+}
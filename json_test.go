@@ -0,0 +1,320 @@
+package liblog
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var tricky = []string{
+	"", "plain ascii", `quotes " and \ backslash`, "tab\tnew\nline\r\x00\x1f",
+	"<html> & co", "кириллица и 漢字 🙂", "bad \xff utf8 \xe2\x82", "sep \u2028 \u2029",
+}
+
+func TestWriteJSON(t *testing.T) {
+	type plain LogMsg
+	for _, s := range tricky {
+		msg := LogMsg{Timestamp: time.Now(), Level: WarningLevel, Message: s, Module: s, SrcFile: "a.go", SrcLine: 7}
+		want, _ := json.Marshal(plain(msg))
+		got, _ := json.Marshal(msg)
+		var gotValue, wantValue map[string]interface{}
+		if err := json.Unmarshal(got, &gotValue); err != nil {
+			t.Errorf("%q: invalid JSON %s: %v", s, got, err)
+		}
+		json.Unmarshal(want, &wantValue)
+		if !reflect.DeepEqual(gotValue, wantValue) {
+			t.Errorf("%q:\n got %s\nwant %s", s, got, want)
+		}
+	}
+}
+
+func BenchmarkEncode(b *testing.B) {
+	type plain LogMsg
+	msg := LogMsg{
+		Timestamp: time.Now(),
+		Level:     InfoLevel,
+		Message:   "GET /api/v1/clients?limit=100 200 12.5ms from 10.0.0.15",
+		Module:    "api-gateway",
+		SrcFile:   "handler.go",
+		SrcLine:   142,
+	}
+	b.Run("liblog", func(b *testing.B) {
+		b.ReportAllocs()
+		var buf bytes.Buffer
+		for i := 0; i < b.N; i++ {
+			buf.Reset()
+			JSONFormatter{}.Format(&buf, &msg, FormatOptions{})
+		}
+	})
+	b.Run("encoding/json", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			json.Marshal(plain(msg))
+		}
+	})
+}
+
+func TestCallerSingleField(t *testing.T) {
+	defer quiet()()
+	logger := Init("caller")
+	defer logger.StopSync()
+
+	logger.SetCallerSingleField(true)
+	var msg map[string]interface{}
+	json.Unmarshal(logger.Render(InfoLevel, "msg"), &msg)
+	if caller, _ := msg["caller"].(string); !strings.HasPrefix(caller, "json_test.go:") {
+		t.Errorf("caller = %v", msg["caller"])
+	}
+	if _, ok := msg["src_file"]; ok {
+		t.Errorf("src_file still written: %v", msg)
+	}
+}
+
+func TestOmitEmptyMessage(t *testing.T) {
+	defer quiet()()
+	logger := Init("event")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+
+	logger.InfoKV("", "event", "login")
+	logger.SetOmitEmptyMessage(true)
+	logger.InfoKV("", "event", "logout")
+	logger.Info("kept")
+	logger.StopSync()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if !strings.Contains(lines[0], `"level":"INFO","message":"","service"`) {
+		t.Errorf("empty message not written by default: %s", lines[0])
+	}
+	if !strings.Contains(lines[1], `"level":"INFO","service"`) || !strings.HasSuffix(lines[1], `"event":"logout"}`) {
+		t.Errorf("empty message not omitted: %s", lines[1])
+	}
+	if !strings.Contains(lines[2], `"message":"kept"`) {
+		t.Errorf("message omitted: %s", lines[2])
+	}
+
+	buf.Reset()
+	msg := LogMsg{Level: InfoLevel, Module: "event"}
+	LogfmtFormatter{}.Format(&buf, &msg, FormatOptions{OmitEmptyMessage: true})
+	if strings.Contains(buf.String(), "message") {
+		t.Errorf("logfmt kept the empty message: %s", buf.String())
+	}
+}
+
+func TestEmitLevelNumber(t *testing.T) {
+	defer quiet()()
+	logger := Init("numeric")
+	defer logger.StopSync()
+
+	if out := logger.Render(ErrorLevel, "x"); strings.Contains(string(out), "levelno") {
+		t.Errorf("levelno written by default: %s", out)
+	}
+	logger.SetEmitLevelNumber(true)
+	if out := logger.Render(ErrorLevel, "x"); !strings.Contains(string(out), `"level":"ERROR","levelno":3,`) {
+		t.Errorf("levelno missing: %s", out)
+	}
+
+	var buf bytes.Buffer
+	msg := LogMsg{Level: WarningLevel, Module: "numeric"}
+	LogfmtFormatter{}.Format(&buf, &msg, FormatOptions{LevelNumber: true})
+	if !strings.Contains(buf.String(), "level=WARNING levelno=2 ") {
+		t.Errorf("logfmt levelno missing: %s", buf.String())
+	}
+}
+
+func TestEmptyModule(t *testing.T) {
+	defer quiet()()
+	logger := Init("")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+	logger.InfoKV("tiny", "k", 1)
+	logger.StopSync()
+
+	var msg map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &msg); err != nil {
+		t.Fatalf("invalid JSON %s: %v", buf.String(), err)
+	}
+	if _, ok := msg["service"]; ok || msg["message"] != "tiny" {
+		t.Errorf("unexpected record: %s", buf.String())
+	}
+}
+
+func TestTimeFormat(t *testing.T) {
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 123456789, time.UTC)
+	msg := LogMsg{Timestamp: ts, Level: InfoLevel, Module: "time"}
+	for format, want := range map[TimeFormat]string{
+		TimeRFC3339Nano:  `{"timestamp":"2020-01-02T03:04:05.123456789Z",`,
+		TimeRFC3339:      `{"timestamp":"2020-01-02T03:04:05Z",`,
+		TimeEpoch:        `{"timestamp":1577934245,`,
+		TimeEpochMilli:   `{"timestamp":1577934245123,`,
+		TimeEpochNano:    `{"timestamp":1577934245123456789,`,
+		TimeRFC3339Milli: `{"timestamp":"2020-01-02T03:04:05.123Z",`,
+		TimeRFC3339Micro: `{"timestamp":"2020-01-02T03:04:05.123456Z",`,
+	} {
+		var buf bytes.Buffer
+		JSONFormatter{}.Format(&buf, &msg, FormatOptions{TimeFormat: format})
+		if !strings.HasPrefix(buf.String(), want) {
+			t.Errorf("format %d: got %s, want prefix %s", format, buf.String(), want)
+		}
+	}
+
+	defer quiet()()
+	os.Setenv("LOGTIME", "EpochMilli")
+	defer os.Unsetenv("LOGTIME")
+	logger := Init("time")
+	if got := logger.formatOptions().TimeFormat; got != TimeEpochMilli {
+		t.Errorf("LOGTIME=EpochMilli gave %d", got)
+	}
+	logger.StopSync()
+	os.Setenv("LOGTIME", "unix")
+	logger = Init("time")
+	if got := logger.formatOptions().TimeFormat; got != TimeRFC3339Nano {
+		t.Errorf("invalid LOGTIME gave %d", got)
+	}
+	logger.StopSync()
+}
+
+func TestWithTimeFormat(t *testing.T) {
+	defer quiet()()
+	logger := Init("time")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+	logger.SetTimeFormat(TimeEpoch)
+
+	logger.Info("coarse")
+	logger.WithTimeFormat(TimeEpochNano).Info("precise")
+	logger.StopSync()
+
+	var records []struct{ Timestamp int64 }
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var r struct{ Timestamp int64 }
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, r)
+	}
+	if records[0].Timestamp > 1e10 || records[1].Timestamp < 1e18 {
+		t.Errorf("unexpected timestamps %v", records)
+	}
+}
+
+func TestEscapeTable(t *testing.T) {
+	defer quiet()()
+	logger := Init("escape")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+
+	logger.InfoKV("a=b <c>", "k=v", "x\x7f=y")
+	table := DefaultEscapeTable()
+	table['='], table[0x7f] = true, true
+	logger.SetEscapeTable(&table)
+	table['a'] = true // the logger keeps its own copy
+	logger.InfoKV("a=b <c>", "k=v", "x\x7f=y")
+	logger.StopSync()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if !strings.Contains(lines[0], `"message":"a=b \u003cc\u003e"`) || !strings.HasSuffix(lines[0], `"k=v":"x`+"\x7f"+`=y"}`) {
+		t.Errorf("default escaping changed: %s", lines[0])
+	}
+	if !strings.Contains(lines[1], `"message":"a\u003db \u003cc\u003e"`) || !strings.HasSuffix(lines[1], `"k\u003dv":"x\u007f\u003dy"}`) {
+		t.Errorf("custom table not applied: %s", lines[1])
+	}
+}
+
+func TestEscapeTableRequired(t *testing.T) {
+	defer quiet()()
+	logger := Init("escape")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+
+	logger.SetEscapeTable(new(EscapeTable))
+	logger.Info("say \"hi\" \\ <there>\n\x01")
+	logger.StopSync()
+
+	var record struct{ Message string }
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil || record.Message != "say \"hi\" \\ <there>\n\x01" {
+		t.Errorf("invalid JSON (%v): %s", err, buf.String())
+	}
+	if !strings.Contains(buf.String(), "<there>") {
+		t.Errorf("unmarked byte escaped: %s", buf.String())
+	}
+}
+
+func BenchmarkEscapeTable(b *testing.B) {
+	msg := LogMsg{
+		Timestamp: time.Now(),
+		Level:     InfoLevel,
+		Message:   "GET /api/v1/clients?limit=100&sort=name 200 12.5ms from 10.0.0.15",
+		Module:    "api-gateway",
+		SrcFile:   "handler.go",
+		SrcLine:   142,
+		Fields:    []Field{{"query", "limit=100&sort=name"}, {"user", "alice"}},
+	}
+	table := DefaultEscapeTable()
+	table['='], table[0x7f] = true, true
+	for _, bench := range []struct {
+		name  string
+		table *EscapeTable
+	}{{"default", nil}, {"custom", &table}} {
+		b.Run(bench.name, func(b *testing.B) {
+			b.ReportAllocs()
+			var buf bytes.Buffer
+			opts := FormatOptions{Escape: bench.table}
+			for i := 0; i < b.N; i++ {
+				buf.Reset()
+				JSONFormatter{}.Format(&buf, &msg, opts)
+			}
+		})
+	}
+}
+
+func TestLineTerminator(t *testing.T) {
+	msg := LogMsg{Level: InfoLevel, Module: "term"}
+	for _, f := range []Formatter{JSONFormatter{}, LogfmtFormatter{}, ConsoleFormatter{}} {
+		var buf bytes.Buffer
+		f.Format(&buf, &msg, FormatOptions{})
+		if !strings.HasSuffix(buf.String(), "\n") || strings.HasSuffix(buf.String(), "\r\n") {
+			t.Errorf("%T: default terminator not \\n: %q", f, buf.String())
+		}
+	}
+
+	defer quiet()()
+	logger := Init("term", WithSynchronous())
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+	logger.SetLineTerminator("\r\n")
+	logger.Info("crlf")
+	logger.SetLineTerminator("\x00")
+	logger.Info("nul")
+	logger.SetLineTerminator("")
+	logger.Info("none")
+	logger.StopSync()
+
+	out := buf.String()
+	if !strings.Contains(out, `"crlf"`) || !strings.Contains(out, "}\r\n{") || !strings.Contains(out, "}\x00{") || !strings.HasSuffix(out, "}") {
+		t.Errorf("terminators not applied: %q", out)
+	}
+}
+
+func TestSeverityMapper(t *testing.T) {
+	msg := LogMsg{Level: FatalLevel, Module: "sev"}
+	var buf bytes.Buffer
+	JSONFormatter{}.Format(&buf, &msg, FormatOptions{Severity: GCPSeverity})
+	if !strings.Contains(buf.String(), `"level":"CRITICAL","severity":600,"message"`) {
+		t.Errorf("GCP severity not applied: %s", buf.String())
+	}
+	buf.Reset()
+	LogfmtFormatter{}.Format(&buf, &msg, FormatOptions{Severity: OTelSeverity})
+	if !strings.Contains(buf.String(), " level=FATAL severity=21 ") {
+		t.Errorf("OTel severity not applied: %s", buf.String())
+	}
+	buf.Reset()
+	JSONFormatter{}.Format(&buf, &msg, FormatOptions{})
+	if strings.Contains(buf.String(), "severity") {
+		t.Errorf("severity written by default: %s", buf.String())
+	}
+}
@@ -0,0 +1,57 @@
+package liblog
+
+import "fmt"
+
+// With returns a logger adding fields built from alternating keys and
+// values, or Field values such as Binary, to every message, sharing
+// everything else with logger.
+func (logger *Logger) With(keyvals ...interface{}) *Logger {
+	child := *logger
+	child.fields = nest(logger.fields, logger.groups, keyvalFields(keyvals))
+	return &child
+}
+
+// keyvalFields pairs up keys and values, taking a Field in place of a key
+// as a whole field. Keys that are not strings are formatted with
+// fmt.Sprint and a key missing its value gets "(MISSING)".
+func keyvalFields(keyvals []interface{}) []Field {
+	if len(keyvals) == 0 {
+		return nil
+	}
+	fields := make([]Field, 0, (len(keyvals)+1)/2)
+	for i := 0; i < len(keyvals); i += 2 {
+		if field, ok := keyvals[i].(Field); ok {
+			fields = append(fields, field)
+			i--
+			continue
+		}
+		key, ok := keyvals[i].(string)
+		if !ok {
+			key = fmt.Sprint(keyvals[i])
+		}
+		var value interface{} = "(MISSING)"
+		if i+1 < len(keyvals) {
+			value = keyvals[i+1]
+		}
+		fields = append(fields, Field{key, value})
+	}
+	return fields
+}
+
+// DebugKV logs msg as is, with fields built from alternating keys and
+// values like With.
+func (logger *Logger) DebugKV(msg string, keyvals ...interface{}) {
+	logger.log(DebugLevel, keyvalFields(keyvals), "%s", msg)
+}
+
+func (logger *Logger) InfoKV(msg string, keyvals ...interface{}) {
+	logger.log(InfoLevel, keyvalFields(keyvals), "%s", msg)
+}
+
+func (logger *Logger) WarningKV(msg string, keyvals ...interface{}) {
+	logger.log(WarningLevel, keyvalFields(keyvals), "%s", msg)
+}
+
+func (logger *Logger) ErrorKV(msg string, keyvals ...interface{}) {
+	logger.log(ErrorLevel, keyvalFields(keyvals), "%s", msg)
+}
@@ -0,0 +1,63 @@
+package liblog
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestKV(t *testing.T) {
+	defer quiet()()
+	logger := Init("kv")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+
+	req := logger.With("method", "GET")
+	req.InfoKV("100% done", "status", 200, 42, "answer", "dangling")
+	logger.WarningKV("plain")
+	logger.StopSync()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines: %s", len(lines), buf.String())
+	}
+	if !strings.Contains(lines[0], `"message":"100% done"`) ||
+		!strings.HasSuffix(lines[0], `"src_file":"kv_test.go","src_line":18,"method":"GET","status":200,"42":"answer","dangling":"(MISSING)"}`) {
+		t.Errorf("unexpected fields: %s", lines[0])
+	}
+	if strings.Contains(lines[1], "method") {
+		t.Errorf("With changed the parent logger: %s", lines[1])
+	}
+}
+
+func TestBinaryField(t *testing.T) {
+	defer quiet()()
+	logger := Init("kv")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+	logger.With(Binary("payload", []byte{0, 0xff, '"'})).InfoKV("received", "size", 3, Binary("crc", []byte{1}))
+	logger.StopSync()
+
+	if !strings.HasSuffix(buf.String(), `"payload":"AP8i","size":3,"crc":"AQ=="}`+"\n") {
+		t.Errorf("unexpected output: %s", buf.String())
+	}
+}
+
+func TestFloatField(t *testing.T) {
+	defer quiet()()
+	logger := Init("kv")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+	logger.InfoKV("floats", Float("big", 1e6, -1), Float("ratio", 2.0/3, 2), Float("nan", math.NaN(), 2), Float("inf", math.Inf(-1), 0))
+	logger.StopSync()
+
+	if !strings.HasSuffix(buf.String(), `"big":1000000,"ratio":0.67,"nan":"NaN","inf":"-Inf"}`+"\n") {
+		t.Errorf("unexpected output: %s", buf.String())
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Errorf("invalid JSON: %v", err)
+	}
+}
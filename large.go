@@ -0,0 +1,73 @@
+package liblog
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"time"
+)
+
+type largeSink struct {
+	threshold int
+	w         io.Writer
+}
+
+// SetLargeMessageSink writes records longer than threshold bytes, once
+// formatted, to w instead of the usual outputs, which get a short record
+// in their place with the same level, time and source, the message
+// "large payload", a "ref" field and the "size" of the full record. The
+// full record written to w carries the same "ref" field: a random 64-bit
+// value in hex, unique for every diverted record, to find it from the
+// reference. Long messages are split before (see LOG_MSG_LEN), so each
+// part is checked on its own. If writing to w fails, the full record is
+// written to the usual outputs. A threshold <= 0 or a nil w turns it off.
+func (logger *Logger) SetLargeMessageSink(threshold int, w io.Writer) {
+	var sink *largeSink
+	if threshold > 0 && w != nil {
+		sink = &largeSink{threshold, w}
+	}
+	logger.mu.Lock()
+	logger.large = sink
+	logger.mu.Unlock()
+}
+
+func (logger *Logger) largeMessageSink() *largeSink {
+	logger.mu.RLock()
+	defer logger.mu.RUnlock()
+	return logger.large
+}
+
+// divert writes msg to the large message sink and replaces the record in
+// buf with a reference to it, unless writing to the sink fails. It returns
+// the message buf now holds, for the writers that format on their own.
+func (logger *Logger) divert(sink *largeSink, formatter Formatter, opts FormatOptions, msg *LogMsg, buf *bytes.Buffer) *LogMsg {
+	var id [8]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		// a reference is only needed to tell the diverted records apart
+		binary.BigEndian.PutUint64(id[:], uint64(time.Now().UnixNano()))
+	}
+	ref := hex.EncodeToString(id[:])
+
+	full := *msg
+	full.Fields = append(msg.Fields[:len(msg.Fields):len(msg.Fields)], Field{"ref", ref})
+	record := getBuffer()
+	defer putBuffer(record)
+	if err := formatter.Format(record, &full, opts); err != nil {
+		internalf("formatter %T failed: %v", formatter, err)
+		return msg
+	}
+	if !logger.write(sink.w, record.Bytes()) {
+		return msg
+	}
+
+	short := *msg
+	short.Message = "large payload"
+	short.Fields = []Field{{"ref", ref}, {"size", record.Len()}}
+	buf.Reset()
+	if err := formatter.Format(buf, &short, opts); err != nil {
+		internalf("formatter %T failed: %v", formatter, err)
+	}
+	return &short
+}
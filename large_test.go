@@ -0,0 +1,60 @@
+package liblog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestLargeMessageSink(t *testing.T) {
+	defer quiet()()
+	logger := Init("large")
+	var main, side bytes.Buffer
+	logger.AddWriter(&main)
+	logger.SetLargeMessageSink(200, &side)
+
+	logger.Info("small")
+	logger.Info("dump: %s", strings.Repeat("x", 300))
+	logger.StopSync()
+
+	lines := strings.Split(strings.TrimSpace(main.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"message":"small"`) {
+		t.Fatalf("unexpected main stream: %s", main.String())
+	}
+	var short, full struct {
+		Message string
+		Ref     string
+		Size    int
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &short); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(side.Bytes(), &full); err != nil {
+		t.Fatal(err)
+	}
+	if short.Message != "large payload" || len(short.Ref) != 16 || short.Size != side.Len() {
+		t.Errorf("unexpected reference record: %s", lines[1])
+	}
+	if full.Ref != short.Ref || !strings.HasPrefix(full.Message, "dump: xxx") {
+		t.Errorf("unexpected diverted record: %s", side.String())
+	}
+}
+
+func TestLargeMessageSinkFormattedWriter(t *testing.T) {
+	defer quiet()()
+	logger := Init("large")
+	var main, side bytes.Buffer
+	logger.AddWriterFormatted(&main, LogfmtFormatter{})
+	logger.SetLargeMessageSink(200, &side)
+
+	logger.Info("dump: %s", strings.Repeat("x", 300))
+	logger.StopSync()
+
+	if strings.Contains(main.String(), "xxx") || !strings.Contains(main.String(), `message="large payload"`) {
+		t.Errorf("formatted writer got the full record: %s", main.String())
+	}
+	if !strings.Contains(side.String(), "dump: xxx") {
+		t.Errorf("unexpected diverted record: %s", side.String())
+	}
+}
@@ -0,0 +1,66 @@
+package liblog
+
+import "fmt"
+
+type lazyValue func() interface{}
+
+// Lazy builds a field whose value is computed by fn only when the record
+// is written, so that expensive values cost nothing on records dropped by
+// the level filter. fn runs on the worker goroutine (on the logging
+// goroutine for synchronous loggers), after the log call has returned:
+// it must be safe to call concurrently with the code that logged and
+// should not read state the caller may have changed since. fn must not
+// log through the same logger, which would deadlock once the queue is
+// full. A panic in fn is recovered and written as the field value.
+func Lazy(key string, fn func() interface{}) Field {
+	return Field{Key: key, Value: lazyValue(fn)}
+}
+
+// resolveLazy replaces the Lazy values of msg, including those in groups,
+// with their results. The fields are copied first since they may be
+// shared with the logger.
+func resolveLazy(msg *LogMsg) {
+	if hasLazy(msg.Fields) {
+		msg.Fields = resolveFields(msg.Fields)
+	}
+}
+
+func hasLazy(fields []Field) bool {
+	for _, field := range fields {
+		switch v := field.Value.(type) {
+		case lazyValue:
+			return true
+		case Fields:
+			if hasLazy(v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func resolveFields(fields []Field) []Field {
+	resolved := make([]Field, len(fields))
+	for i, field := range fields {
+		switch v := field.Value.(type) {
+		case lazyValue:
+			field.Value = v.call()
+		case Fields:
+			if hasLazy(v) {
+				field.Value = Fields(resolveFields(v))
+			}
+		}
+		resolved[i] = field
+	}
+	return resolved
+}
+
+func (fn lazyValue) call() (value interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			internalf("lazy field panicked: %v", r)
+			value = fmt.Sprintf("(PANIC=%v)", r)
+		}
+	}()
+	return fn()
+}
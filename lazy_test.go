@@ -0,0 +1,33 @@
+package liblog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLazy(t *testing.T) {
+	defer quiet()()
+	logger := Init("lazy")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+	logger.SetLevel(InfoLevel)
+
+	calls := 0
+	expensive := func() interface{} { calls++; return []int{1, 2} }
+	logger.DebugKV("dropped", Lazy("payload", expensive))
+	logger.Group("req").InfoKV("kept", Lazy("payload", expensive))
+	logger.InfoKV("broken", Lazy("payload", func() interface{} { panic("boom") }))
+	logger.StopSync()
+
+	if calls != 1 {
+		t.Errorf("lazy field computed %d times, want 1", calls)
+	}
+	out := buf.String()
+	if !strings.Contains(out, `"req":{"payload":[1,2]}`) {
+		t.Errorf("lazy field not resolved in group: %s", out)
+	}
+	if !strings.Contains(out, `"payload":"(PANIC=boom)"`) {
+		t.Errorf("panicking lazy field not recovered: %s", out)
+	}
+}
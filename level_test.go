@@ -0,0 +1,114 @@
+package liblog
+
+import (
+	"bytes"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSharedLevel(t *testing.T) {
+	defer quiet()()
+	shared := NewAtomicLevel(InfoLevel)
+	first := Init("first", WithSharedLevel(shared))
+	second := Init("second", WithSharedLevel(shared))
+	var firstBuf, secondBuf bytes.Buffer
+	first.AddWriter(&firstBuf)
+	second.AddWriter(&secondBuf)
+
+	first.Debug("hidden")
+	rec := httptest.NewRecorder()
+	shared.ServeHTTP(rec, httptest.NewRequest("PUT", "/", strings.NewReader(`{"level":"debug"}`)))
+	if rec.Code != 200 || second.GetLevel() != DebugLevel {
+		t.Fatalf("level not changed: %d %s", rec.Code, second.LevelString())
+	}
+	second.Debug("shown")
+	second.StopSync()
+	second.SetLevel(ErrorLevel)
+	first.Info("hidden again")
+	first.StopSync()
+
+	if out := firstBuf.String(); out != "" {
+		t.Errorf("unexpected output: %s", out)
+	}
+	if out := secondBuf.String(); !strings.Contains(out, "shown") {
+		t.Errorf("unexpected output: %s", out)
+	}
+	if shared.Get() != ErrorLevel {
+		t.Error("SetLevel did not change the shared level")
+	}
+}
+
+func TestRegisterLevel(t *testing.T) {
+	defer quiet()()
+	notice := LogLevel(5)
+	if s := notice.String(); s != "LEVEL5" {
+		t.Errorf("unregistered level is %s", s)
+	}
+	RegisterLevel(notice, "NOTICE")
+	defer func() {
+		registry.Lock()
+		delete(registry.names, notice)
+		registry.Unlock()
+	}()
+	if level, err := ParseLevel("notice"); level != notice || err != nil {
+		t.Errorf("ParseLevel(notice) = %v, %v", level, err)
+	}
+	if s := InfoLevel.String(); s != "INFO" {
+		t.Errorf("built-in level is %s", s)
+	}
+
+	logger := Init("levels")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+	fmt.Fprint(logger.LevelWriterWith(notice, nil), "custom")
+	logger.StopSync()
+	if !strings.Contains(buf.String(), `"level":"NOTICE","message":"custom"`) {
+		t.Errorf("custom level not named: %s", buf.String())
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	defer quiet()()
+	StopSyncSingle()
+	if ErrorEnabled() {
+		t.Errorf("enabled without a singleton")
+	}
+	logger := InitSingleStr("enabled")
+	defer StopSyncSingle()
+	logger.SetLevel(WarningLevel)
+	if logger.DebugEnabled() || logger.InfoEnabled() || !logger.WarningEnabled() || !logger.ErrorEnabled() {
+		t.Errorf("wrong levels enabled at WARNING")
+	}
+	logger.SetLevel(DebugLevel)
+	if !DebugEnabled() || !InfoEnabled() {
+		t.Errorf("debug not enabled for the singleton")
+	}
+}
+
+func TestWithLevel(t *testing.T) {
+	defer quiet()()
+	logger := Init("scoped")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+
+	debug := logger.WithLevel(DebugLevel).Named("op")
+	quietScope := logger.WithLevel(ErrorLevel)
+	if !debug.DebugEnabled() || quietScope.WarningEnabled() || logger.DebugEnabled() {
+		t.Error("Enabled ignores the scoped level")
+	}
+	debug.Debug("scoped debug")
+	logger.Debug("parent debug")
+	quietScope.Warning("scoped warning")
+	logger.Warning("parent warning")
+	logger.StopSync()
+
+	out := buf.String()
+	if !strings.Contains(out, "scoped debug") || !strings.Contains(out, "parent warning") {
+		t.Errorf("scoped level not applied: %s", out)
+	}
+	if strings.Contains(out, "parent debug") || strings.Contains(out, "scoped warning") {
+		t.Errorf("scoped level leaked: %s", out)
+	}
+}
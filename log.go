@@ -1,67 +1,291 @@
 package liblog
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
+	"math"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 )
 
-type LogLevel int
+type LogLevel int32
 
 var DebugLevel LogLevel = LogLevel(0)
 var InfoLevel LogLevel = LogLevel(1)
 var WarningLevel LogLevel = LogLevel(2)
 var ErrorLevel LogLevel = LogLevel(3)
+var FatalLevel LogLevel = LogLevel(4)
 
 var MaxMsgLength int = 8000
 
-func (l LogLevel) MarshalJSON() ([]byte, error) {
+func (l LogLevel) String() string {
+	if name, ok := registeredName(l); ok {
+		return name
+	}
 	switch l {
 	case DebugLevel:
-		return json.Marshal("DEBUG")
+		return "DEBUG"
 	case InfoLevel:
-		return json.Marshal("INFO")
+		return "INFO"
 	case WarningLevel:
-		return json.Marshal("WARNING")
+		return "WARNING"
 	case ErrorLevel:
-		return json.Marshal("ERROR")
+		return "ERROR"
+	case FatalLevel:
+		return "FATAL"
+	}
+	return fmt.Sprintf("LEVEL%d", l)
+}
+
+func (l LogLevel) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.String())
+}
+
+// ParseLevel converts a level name (case-insensitive, common aliases and
+// names given to RegisterLevel included) or its number into a LogLevel.
+func ParseLevel(s string) (LogLevel, error) {
+	if level, ok := registeredLevel(strings.TrimSpace(s)); ok {
+		return level, nil
+	}
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case "DEBUG", "DBG", "0":
+		return DebugLevel, nil
+	case "INFO", "1":
+		return InfoLevel, nil
+	case "WARNING", "WARN", "2":
+		return WarningLevel, nil
+	case "ERROR", "ERR", "3":
+		return ErrorLevel, nil
+	case "FATAL", "4":
+		return FatalLevel, nil
 	}
-	return json.Marshal(fmt.Sprintf("LEVEL%d", l))
+	return InfoLevel, fmt.Errorf("liblog: unknown log level %q", s)
 }
 
 type LogMsg struct {
 	Timestamp time.Time `json:"timestamp"`
 	Level     LogLevel  `json:"level"`
 	Message   string    `json:"message"`
-	Module    string    `json:"service"`
+	Module    string    `json:"service,omitempty"`
 	ModuleId  string    `json:"service_id,omitempty"`
+	Logger    string    `json:"logger,omitempty"`
 	SrcFile   string    `json:"src_file,omitempty"`
 	SrcLine   int       `json:"src_line,omitempty"`
+	// SrcFunc is only set when enabled with SetCaptureFunction
+	SrcFunc string `json:"src_func,omitempty"`
+	// GoroutineId is only set when enabled with SetCaptureGoroutineID
+	GoroutineId uint64  `json:"goid,omitempty"`
+	Fields      []Field `json:"-"`
+	// timeFormat overrides FormatOptions.TimeFormat, see WithTimeFormat
+	timeFormat *TimeFormat
+	// scopeLevel overrides the logger level, see WithLevel
+	scopeLevel *LogLevel
 }
 
+// Field is an additional key/value pair written at the top level of a message.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Logger writes messages through a pipeline (queue, worker, writers and
+// settings) shared with the loggers derived from it by Named and With.
 type Logger struct {
-	module  string
-	id      string
-	output  chan LogMsg
-	Level   LogLevel
-	writers []io.Writer
-	stop    chan bool
-	msgLen  int
+	*core
+	name       string
+	fields     []Field
+	groups     []string
+	timeFormat *TimeFormat
+	scopeLevel *LogLevel
+}
+
+type core struct {
+	// accessed atomically, kept first for 64-bit alignment
+	dropped      uint64
+	dropReported uint64
+	// emitted counts written messages per level, Debug to Fatal
+	emitted          [5]uint64
+	dropNoticeAt     int64
+	dropNoticeEvery  int64
+	maxMsgBytes      int64
+	maxStackDepth    int64
+	lastDropAt       int64
+	preAlloc         int64
+	recordSize       int64
+	captureGoid      int32
+	captureFunc      int32
+	splitStreams     int32
+	silentDrop       int32
+	writePolicy      int32
+	paused           int32
+	pauseBypass      int32
+	structuredStacks int32
+	running          int32
+	// noFailsafe disables SetFailsafeStderr, on by default
+	noFailsafe    int32
+	versionField  int32
+	syncFrom      int32
+	backpressured int32
+	signalReraise int32
+
+	module string
+	id     string
+	output chan LogMsg
+	// Level may be assigned before logging starts; use SetLevel to change
+	// it while messages are being logged. It is ignored when the logger
+	// was created with WithSharedLevel.
+	Level       LogLevel
+	sharedLevel *AtomicLevel
+	writers     []io.Writer
+	msgLen      int
+	mu          sync.RWMutex
+	stdout      io.Writer
+	// stderr is os.Stderr as of Init unless set with WithErrorOutput
+	stderr io.Writer
+	// ownedOutput is the file opened for LOGOUTPUT, closed on stop
+	ownedOutput io.Closer
+	buffer      *stdoutBuffer
+	format      FormatOptions
+	formatter   Formatter
+	throttle    throttle
+	health      health
+	routes      []*route
+	ring        *ring
+	// errorContext holds records until an error, see EnableErrorContextBuffer
+	errorContext *errorContext
+	large        *largeSink
+	redactor     *redactor
+	defaults     []Field
+	hook         func(level LogLevel, formatted []byte)
+	broken       brokenWriters
+	// done is closed once the worker has written everything
+	done        chan struct{}
+	writerLevel LogLevel
+	// synchronous loggers write messages from log() without a worker
+	synchronous bool
+	syncMu      sync.Mutex
+	closeMu     sync.RWMutex
+	closed      bool
+	// dropFull makes log() drop messages instead of blocking when the
+	// output queue is full
+	dropFull bool
+	// adaptive, when set, takes messages instead of output
+	adaptive     *adaptiveQueue
+	exitFunc     func(code int)
+	backpressure func(dropping bool)
+	// heartbeat stops the EnableHeartbeat goroutine when closed
+	heartbeat chan struct{}
+	pause     pauseBuffer
 }
 
 var singleLogger *Logger
 
 func (logger *Logger) printMessage(msg LogMsg) {
-	if msg.Level < logger.Level {
+	level, ring, held := logger.GetLevel(), logger.ringBuffer(), logger.errorContextBuffer()
+	if msg.scopeLevel != nil {
+		level = *msg.scopeLevel
+	}
+	keep := ring != nil && ring.keeps(msg.Level, level)
+	filtered := msg.Level < level
+	hold := held != nil && msg.Level < ErrorLevel
+	if filtered && !keep && !hold {
 		return
 	}
+	if !filtered && !hold {
+		logger.countEmitted(msg.Level)
+	}
+	logger.mergeFields(&msg)
+	logger.redact(&msg)
+	resolveLazy(&msg)
+	logger.addVersion(&msg)
+	logger.truncate(&msg)
+	formatter, opts := logger.currentFormatter(), logger.formatOptions()
+	if msg.timeFormat != nil {
+		opts.TimeFormat = *msg.timeFormat
+	}
+	hook, large := logger.postFormatHook(), logger.largeMessageSink()
+	route := logger.route(&msg)
+	logger.split(msg, func(part *LogMsg) {
+		buf := getBuffer()
+		logger.preGrow(buf)
+		if err := formatter.Format(buf, part, opts); err != nil {
+			internalf("formatter %T failed: %v", formatter, err)
+			putBuffer(buf)
+			return
+		}
+		logger.observeRecord(buf.Len())
+		if large != nil && buf.Len() > large.threshold && !filtered && !hold {
+			part = logger.divert(large, formatter, opts, part, buf)
+		}
+		if keep {
+			ring.add(buf.Bytes())
+		}
+		if filtered || hold {
+			if hold {
+				held.add(part.Level, buf.Bytes())
+			}
+			putBuffer(buf)
+			return
+		}
+		if held != nil {
+			held.flush(func(level LogLevel, data []byte) { logger.writeAll(level, &record{data: data}) })
+		}
+		if hook != nil {
+			hook(part.Level, buf.Bytes())
+		}
+		rec := &record{msg: part, data: buf.Bytes(), opts: opts}
+		ok := route != nil && len(route.writers) == 0
+		if route != nil {
+			for _, w := range route.writers {
+				if logger.writeTo(w, rec) {
+					ok = true
+				}
+			}
+		} else {
+			ok = logger.writeAll(part.Level, rec)
+		}
+		if !ok {
+			logger.failsafe(buf.Bytes())
+		}
+		rec.release()
+		putBuffer(buf)
+	})
+}
+
+// SetMaxMessageBytes truncates messages longer than n bytes, marking them
+// with "...(truncated)" and a "truncated":true field. Only the message is
+// limited, not fields. 0, the default, means no limit. Truncation happens
+// before long messages are split into several records.
+func (logger *Logger) SetMaxMessageBytes(n int) {
+	atomic.StoreInt64(&logger.maxMsgBytes, int64(n))
+}
+
+func (logger *Logger) truncate(msg *LogMsg) {
+	max := int(atomic.LoadInt64(&logger.maxMsgBytes))
+	if max <= 0 || len(msg.Message) <= max {
+		return
+	}
+	for max > 0 && !utf8.RuneStart(msg.Message[max]) {
+		max--
+	}
+	msg.Message = msg.Message[:max] + "...(truncated)"
+	msg.Fields = append(msg.Fields[:len(msg.Fields):len(msg.Fields)], Field{"truncated", true})
+}
+
+// split passes msg to emit, split into several messages if it is too long.
+func (logger *Logger) split(msg LogMsg, emit func(msg *LogMsg)) {
 	for len(msg.Message) > logger.msgLen {
 		text := msg.Message
 		index := -1
@@ -82,115 +306,460 @@ func (logger *Logger) printMessage(msg LogMsg) {
 			msgPart.Message = text[:index]
 			text = text[index+1:]
 		}
-		bytestring, _ := json.Marshal(msgPart)
-		fmt.Printf("%s\n", string(bytestring))
-		for _, w := range logger.writers {
-			fmt.Fprintf(w, "%s\n", string(bytestring))
-		}
+		emit(&msgPart)
 		msg.Message = text
 	}
-	bytestring, _ := json.Marshal(msg)
-	bytestring = append(bytestring, byte('\n'))
-	os.Stdout.Write(bytestring)
-	for _, w := range logger.writers {
-		w.Write(bytestring)
+	emit(&msg)
+}
+
+// Render returns the bytes the logger would write for the message, without
+// queueing or writing it. The level filter is not applied.
+func (logger *Logger) Render(level LogLevel, format string, values ...interface{}) []byte {
+	pc, fileName, lineNumber, _ := runtime.Caller(1)
+	msg := logger.newMsg(level, formatMessage(format, values))
+	msg.SrcFile = filepath.Base(fileName)
+	msg.SrcLine = lineNumber
+	msg.SrcFunc = logger.funcName(pc)
+	logger.truncate(&msg)
+	formatter, opts := logger.currentFormatter(), logger.formatOptions()
+	if msg.timeFormat != nil {
+		opts.TimeFormat = *msg.timeFormat
+	}
+	var buf bytes.Buffer
+	logger.split(msg, func(part *LogMsg) {
+		formatter.Format(&buf, part, opts)
+	})
+	return buf.Bytes()
+}
+
+// writeAll passes each record to every writer in a single Write call, so
+// records written to a file opened with O_APPEND are not interleaved with
+// those of other processes.
+// writeAll reports whether at least one writer took the whole record. A
+// stdout switched off with ioutil.Discard does not count as a writer, so
+// the record is only reported as written without any writer at all.
+func (logger *Logger) writeAll(level LogLevel, rec *record) bool {
+	ok, discarded := false, false
+	if level >= WarningLevel && atomic.LoadInt32(&logger.splitStreams) == 1 {
+		ok = logger.write(logger.stderrWriter(), rec.data)
+	} else if buffer := logger.stdoutBuffer(); buffer != nil {
+		ok = buffer.write(rec.data)
+	} else if stdout := logger.stdoutWriter(); stdout != ioutil.Discard {
+		ok = logger.writeTo(stdout, rec)
+	} else {
+		discarded = true
+	}
+	writers := logger.currentWriters()
+	for _, w := range writers {
+		if logger.writeTo(w, rec) {
+			ok = true
+		}
+	}
+	return ok || discarded && len(writers) == 0
+}
+
+// writeSafe keeps a panicking writer from killing the worker goroutine.
+func writeSafe(w io.Writer, data []byte) (n int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			internalf("writer %T panicked: %v", w, r)
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return w.Write(data)
+}
+
+func (logger *Logger) stdoutWriter() io.Writer {
+	logger.mu.RLock()
+	defer logger.mu.RUnlock()
+	if logger.stdout != nil {
+		return logger.stdout
+	}
+	return os.Stdout
+}
+
+func (logger *Logger) stderrWriter() io.Writer {
+	logger.mu.RLock()
+	defer logger.mu.RUnlock()
+	return logger.stderr
+}
+
+func (logger *Logger) setStdout(w io.Writer) io.Writer {
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	prev := logger.stdout
+	logger.stdout = w
+	return prev
+}
+
+func (logger *Logger) newMsg(level LogLevel, message string) LogMsg {
+	var goid uint64
+	if atomic.LoadInt32(&logger.captureGoid) != 0 {
+		goid = goroutineID()
+	}
+	logger.mu.RLock()
+	defer logger.mu.RUnlock()
+	return LogMsg{
+		Timestamp:   time.Now(),
+		Level:       level,
+		Module:      logger.module,
+		ModuleId:    logger.id,
+		Message:     message,
+		Logger:      logger.name,
+		GoroutineId: goid,
+		timeFormat:  logger.timeFormat,
+		scopeLevel:  logger.scopeLevel,
+	}
+}
+
+func (logger *Logger) log(level LogLevel, fields []Field, format string, values ...interface{}) {
+	logger.logDepth(3, level, fields, format, values...)
+}
+
+// logDepth takes the source location from the given runtime.Caller depth,
+// counted from logDepth itself; depth 0 leaves it out.
+func (logger *Logger) logDepth(depth int, level LogLevel, fields []Field, format string, values ...interface{}) {
+	if depth > 0 {
+		depth++
+	}
+	logger.send(logger.message(depth, level, fields, format, values...))
+}
+
+// message builds a message like logDepth, with depth counted from message.
+func (logger *Logger) message(depth int, level LogLevel, fields []Field, format string, values ...interface{}) LogMsg {
+	msg := logger.newMsg(level, formatMessage(format, values))
+	if len(logger.groups) > 0 && len(fields) > 0 {
+		fields = nest(logger.fields, logger.groups, fields)
+	} else if len(logger.fields) > 0 {
+		fields = append(logger.fields[:len(logger.fields):len(logger.fields)], fields...)
+	}
+	msg.Fields = fields
+	if depth > 0 {
+		pc, fileName, lineNumber, _ := runtime.Caller(depth)
+		msg.SrcFile = filepath.Base(fileName)
+		msg.SrcLine = lineNumber
+		msg.SrcFunc = logger.funcName(pc)
 	}
+	return msg
 }
 
-func (logger *Logger) log(level LogLevel, format string, values ...interface{}) {
-	_, fileName, lineNumber, _ := runtime.Caller(2)
-	logger.output <- LogMsg{
-		Timestamp: time.Now(),
-		Level:     level,
-		Module:    logger.module,
-		ModuleId:  logger.id,
-		Message:   fmt.Sprintf(format, values...),
-		SrcFile:   filepath.Base(fileName),
-		SrcLine:   lineNumber,
+func (logger *Logger) send(msg LogMsg) {
+	logger.closeMu.RLock()
+	defer logger.closeMu.RUnlock()
+	if logger.closed {
+		return
+	}
+	if taken, _ := logger.pauseTake(msg); taken {
+		return
 	}
+	logger.enqueue(msg)
+}
+
+// enqueue hands msg to the worker, or writes it, for send once the logger
+// is known to be open and not paused.
+func (logger *Logger) enqueue(msg LogMsg) {
+	if logger.synchronous {
+		logger.syncMu.Lock()
+		logger.printMessage(msg)
+		logger.syncMu.Unlock()
+		return
+	}
+	if logger.syncFor(msg.Level) {
+		logger.writeInline(msg)
+		return
+	}
+	if logger.adaptive != nil {
+		logger.adaptive.intake <- msg
+		return
+	}
+	if !logger.dropFull {
+		logger.output <- msg
+		return
+	}
+	select {
+	case logger.output <- msg:
+	default:
+		logger.drop()
+	}
+}
+
+// formatMessage skips fmt for the common message without arguments, which
+// is then written as is: "100%" stays "100%" and "%%" is not unescaped.
+func formatMessage(format string, values []interface{}) string {
+	if len(values) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, values...)
 }
 
 // OBJECT
 
-func Init(module string) *Logger {
-	var logger = new(Logger)
+func Init(module string, options ...Option) *Logger {
+	if valid, err := validateModule(module); err != nil {
+		internalf("invalid module name %q: %v", module, err)
+	} else {
+		module = valid
+	}
+	return initLogger(module, options...)
+}
+
+func initLogger(module string, options ...Option) *Logger {
+	var logger = &Logger{core: new(core)}
 	logger.module = module
-	logger.output = make(chan LogMsg)
+	logger.dropNoticeEvery = int64(time.Second)
+	logger.pauseBypass = int32(FatalLevel)
+	logger.syncFrom = math.MaxInt32
 	logger.writers = make([]io.Writer, 0)
-	logger.stop = make(chan bool)
-	level := os.Getenv("LOGLEVEL")
-	switch level {
-	case "ERROR", "3":
-		logger.Level = ErrorLevel
-	case "WARNING", "2":
-		logger.Level = WarningLevel
-	case "DEBUG", "0":
-		logger.Level = DebugLevel
-	default:
-		logger.Level = InfoLevel
-	}
+	logger.Level, _ = ParseLevel(os.Getenv("LOGLEVEL"))
+	logger.writerLevel = InfoLevel
+	logger.format.TimeFormat = timeFormatFromEnv()
 	logger.msgLen, _ = strconv.Atoi(os.Getenv("LOG_MSG_LEN"))
 	if logger.msgLen == 0 {
 		logger.msgLen = MaxMsgLength
 	}
+	for _, option := range options {
+		option(logger)
+	}
+	formatterSet := logger.formatter != nil
+	if !formatterSet {
+		logger.formatter = formatterFromEnv()
+	}
+	if logger.stdout == nil {
+		logger.stdout, logger.ownedOutput = outputFromEnv()
+	}
+	if logger.stderr == nil {
+		logger.stderr = os.Stderr
+	}
+	if !formatterSet && strings.EqualFold(os.Getenv("LOGFORMAT"), "auto") {
+		logger.AutoFormat()
+	}
+	queueLen, _ := strconv.Atoi(os.Getenv("LOG_QUEUE_LEN"))
+	logger.start(queueLen)
+	return logger
+}
+
+// start creates the queue, dropping messages when it is full if queueLen
+// is positive, and starts the worker.
+func (logger *Logger) start(queueLen int) {
+	logger.done = make(chan struct{})
+	if logger.synchronous {
+		return
+	}
+	if queueLen > 0 {
+		logger.output = make(chan LogMsg, queueLen)
+		logger.dropFull = true
+	} else {
+		logger.output = make(chan LogMsg)
+	}
+	atomic.StoreInt32(&logger.running, 1)
 	go func() {
+		defer atomic.StoreInt32(&logger.running, 0)
 		for msg := range logger.output {
+			logger.syncMu.Lock()
 			logger.printMessage(msg)
+			logger.syncMu.Unlock()
 			runtime.Gosched()
 		}
-		logger.stop <- true
+		logger.finish()
 	}()
-	return logger
 }
 
 func (logger *Logger) Debug(format string, values ...interface{}) {
-	logger.log(DebugLevel, format, values...)
+	logger.log(DebugLevel, nil, format, values...)
 }
 
 func (logger *Logger) Info(format string, values ...interface{}) {
-	logger.log(InfoLevel, format, values...)
+	logger.log(InfoLevel, nil, format, values...)
 }
 
 func (logger *Logger) Warning(format string, values ...interface{}) {
-	logger.log(WarningLevel, format, values...)
+	logger.log(WarningLevel, nil, format, values...)
 }
 
 func (logger *Logger) Error(format string, values ...interface{}) {
-	logger.log(ErrorLevel, format, values...)
+	logger.log(ErrorLevel, nil, format, values...)
+}
+
+// LogAt logs with the given source location instead of the caller's, for
+// generated code and wrappers that know the real call site. Only the base
+// name of file is written, as for other messages. An empty file falls back
+// to the caller's location.
+func (logger *Logger) LogAt(file string, line int, level LogLevel, format string, values ...interface{}) {
+	if file == "" {
+		logger.log(level, nil, format, values...)
+		return
+	}
+	msg := logger.message(0, level, nil, format, values...)
+	msg.SrcFile = filepath.Base(file)
+	msg.SrcLine = line
+	logger.send(msg)
 }
 
 func (logger *Logger) Stop() {
-	close(logger.output)
+	logger.closeOutput()
 }
 
 func (logger *Logger) StopSync() {
-	close(logger.output)
-	<-logger.stop
-	close(logger.stop)
+	logger.closeOutput()
+	<-logger.done
+}
+
+// closeOutput makes the logger ignore new messages and lets the worker
+// finish once the queue is empty.
+func (logger *Logger) closeOutput() {
+	logger.closeMu.Lock()
+	defer logger.closeMu.Unlock()
+	if logger.closed {
+		return
+	}
+	logger.closed = true
+	if logger.heartbeat != nil {
+		close(logger.heartbeat)
+		logger.heartbeat = nil
+	}
+	switch {
+	case logger.synchronous:
+		logger.finish()
+	case logger.adaptive != nil:
+		close(logger.adaptive.intake)
+	default:
+		close(logger.output)
+	}
+}
+
+// finish runs once everything has been written.
+func (logger *Logger) finish() {
+	logger.Flush()
+	if logger.ownedOutput != nil {
+		logger.ownedOutput.Close()
+	}
+	close(logger.done)
+}
+
+// Write logs p as a message at the writer level (Info unless changed with
+// SetWriterLevel), so the logger itself can be used as an io.Writer.
+func (logger *Logger) Write(p []byte) (n int, err error) {
+	level := LogLevel(atomic.LoadInt32((*int32)(&logger.writerLevel)))
+	logger.log(level, nil, string(p))
+	return len(p), nil
+}
+
+func (logger *Logger) SetWriterLevel(level LogLevel) {
+	atomic.StoreInt32((*int32)(&logger.writerLevel), int32(level))
+}
+
+// Close stops the logger waiting for queued messages to be written.
+func (logger *Logger) Close() error {
+	logger.StopSync()
+	return nil
 }
 
+// LogWriter logs everything written to it as messages. The source location
+// of a write rarely means anything, so by default it is replaced by a
+// "source":"writer" field; see SetCallerSkip.
 type LogWriter struct {
-	host  *Logger
-	level LogLevel
+	host       *Logger
+	level      LogLevel
+	fields     []Field
+	callerSkip int
+
+	mu         sync.Mutex
+	splitLines bool
+	partial    []byte
 }
 
 func (writer *LogWriter) Write(p []byte) (n int, err error) {
-	msg := string(p)
-	writer.host.log(writer.level, msg)
+	if !writer.splitLines {
+		if len(bytes.TrimSpace(p)) > 0 {
+			writer.emit(string(p))
+		}
+		return len(p), nil
+	}
+	writer.mu.Lock()
+	defer writer.mu.Unlock()
+	data := append(writer.partial, p...)
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		if line := bytes.TrimRight(data[:i], "\r"); len(bytes.TrimSpace(line)) > 0 {
+			writer.emit(string(line))
+		}
+		data = data[i+1:]
+	}
+	writer.partial = append(writer.partial[:0], data...)
 	return len(p), nil
 }
 
+// SetSplitLines makes Write log every non-blank line as its own message.
+// A write not ending in a newline keeps its last line until the rest of
+// it arrives or Flush is called.
+func (writer *LogWriter) SetSplitLines(split bool) {
+	writer.mu.Lock()
+	writer.splitLines = split
+	writer.mu.Unlock()
+}
+
+// Flush logs a pending partial line left by a split-lines Write.
+func (writer *LogWriter) Flush() {
+	writer.mu.Lock()
+	defer writer.mu.Unlock()
+	if len(bytes.TrimSpace(writer.partial)) > 0 {
+		writer.emit(string(writer.partial))
+	}
+	writer.partial = writer.partial[:0]
+}
+
+// emit must be called directly from Write or Flush for the caller skip to
+// point at the right frame.
+func (writer *LogWriter) emit(msg string) {
+	if writer.callerSkip > 0 {
+		writer.host.logDepth(writer.callerSkip+2, writer.level, writer.fields, "%s", msg)
+	} else {
+		writer.host.logDepth(0, writer.level, writer.withSource(), "%s", msg)
+	}
+}
+
+func (writer *LogWriter) withSource() []Field {
+	fields := make([]Field, 0, len(writer.fields)+1)
+	fields = append(fields, writer.fields...)
+	return append(fields, Field{"source", "writer"})
+}
+
+// SetCallerSkip makes the writer record the source location of the
+// function skip frames above Write: 1 is the direct caller of Write, e.g.
+// 3 for a log.Logger's Printf caller. 0 turns it off again.
+func (writer *LogWriter) SetCallerSkip(skip int) {
+	writer.callerSkip = skip
+}
+
 func (logger *Logger) DebugWriter() io.Writer {
-	return &LogWriter{logger, DebugLevel}
+	return &LogWriter{host: logger, level: DebugLevel}
 }
 func (logger *Logger) InfoWriter() io.Writer {
-	return &LogWriter{logger, InfoLevel}
+	return &LogWriter{host: logger, level: InfoLevel}
 }
 func (logger *Logger) WarningWriter() io.Writer {
-	return &LogWriter{logger, WarningLevel}
+	return &LogWriter{host: logger, level: WarningLevel}
 }
 func (logger *Logger) ErrorWriter() io.Writer {
-	return &LogWriter{logger, ErrorLevel}
+	return &LogWriter{host: logger, level: ErrorLevel}
+}
+
+// LevelWriterWith returns a writer logging at the given level and adding
+// fields to every message written through it.
+func (logger *Logger) LevelWriterWith(level LogLevel, fields map[string]interface{}) io.Writer {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	writer := &LogWriter{host: logger, level: level, fields: make([]Field, 0, len(keys))}
+	for _, key := range keys {
+		writer.fields = append(writer.fields, Field{key, fields[key]})
+	}
+	return writer
 }
 func (logger *Logger) DebugLogger(prefix string, flags int) *log.Logger {
 	return log.New(logger.DebugWriter(), prefix, flags)
@@ -205,12 +774,95 @@ func (logger *Logger) ErrorLogger(prefix string, flags int) *log.Logger {
 	return log.New(logger.ErrorWriter(), prefix, flags)
 }
 
+// SetLevel changes the level, for every logger using it if the level is
+// shared (see WithSharedLevel).
+func (logger *Logger) SetLevel(level LogLevel) {
+	if logger.sharedLevel != nil {
+		logger.sharedLevel.Set(level)
+		return
+	}
+	atomic.StoreInt32((*int32)(&logger.Level), int32(level))
+}
+
+func (logger *Logger) GetLevel() LogLevel {
+	if logger.sharedLevel != nil {
+		return logger.sharedLevel.Get()
+	}
+	return LogLevel(atomic.LoadInt32((*int32)(&logger.Level)))
+}
+
+// SetLevelString changes the level to one accepted by ParseLevel.
+func (logger *Logger) SetLevelString(s string) error {
+	level, err := ParseLevel(s)
+	if err != nil {
+		return err
+	}
+	logger.SetLevel(level)
+	return nil
+}
+
+func (logger *Logger) LevelString() string {
+	return logger.GetLevel().String()
+}
+
 func (logger *Logger) AddWriter(writer io.Writer) {
+	logger.mu.Lock()
 	logger.writers = append(logger.writers, writer)
+	logger.mu.Unlock()
+}
+
+// Writers returns a copy of the writers added with AddWriter and
+// AddWriterFormatted.
+func (logger *Logger) Writers() []io.Writer {
+	writers := append([]io.Writer(nil), logger.currentWriters()...)
+	for i, w := range writers {
+		if fw, ok := w.(*formattedWriter); ok {
+			writers[i] = fw.Writer
+		}
+	}
+	return writers
+}
+
+// currentWriters returns the writers without copying them: AddWriter only
+// ever appends, so the returned slice stays valid.
+func (logger *Logger) currentWriters() []io.Writer {
+	logger.mu.RLock()
+	defer logger.mu.RUnlock()
+	return logger.writers
 }
 
 func (logger *Logger) SetModuleId(id string) {
+	logger.mu.Lock()
 	logger.id = id
+	logger.mu.Unlock()
+}
+
+// Named returns a logger sharing the pipeline of this one and adding name
+// to its "logger" field, e.g. "api.auth.jwt" for
+// logger.Named("api").Named("auth").Named("jwt").
+func (logger *Logger) Named(name string) *Logger {
+	child := *logger
+	switch {
+	case name == "":
+	case logger.name == "":
+		child.name = name
+	default:
+		child.name = logger.name + "." + name
+	}
+	return &child
+}
+
+// SetModule changes the service name. Messages already logged keep the
+// name they were logged with.
+func (logger *Logger) SetModule(module string) {
+	valid, err := validateModule(module)
+	if err != nil {
+		internalf("invalid module name %q: %v", module, err)
+		return
+	}
+	logger.mu.Lock()
+	logger.module = valid
+	logger.mu.Unlock()
 }
 
 // SINGLETON
@@ -226,28 +878,75 @@ func InitSingleStr(module string) *Logger {
 	return singleLogger
 }
 
+// InitSingleFromEnv initializes the singleton with the module named by
+// LOGMODULE, or the binary name when it is unset, and the module id from
+// LOGMODULE_ID. An already initialized singleton is returned unchanged.
+func InitSingleFromEnv() *Logger {
+	if singleLogger == nil {
+		module := os.Getenv("LOGMODULE")
+		if module == "" {
+			module = filepath.Base(os.Args[0])
+		}
+		singleLogger = Init(module)
+		if id := os.Getenv("LOGMODULE_ID"); id != "" {
+			singleLogger.SetModuleId(id)
+		}
+	}
+	return singleLogger
+}
+
 func Debug(format string, values ...interface{}) {
 	if singleLogger != nil {
-		singleLogger.log(DebugLevel, format, values...)
+		singleLogger.log(DebugLevel, nil, format, values...)
 	}
 }
 
 func Info(format string, values ...interface{}) {
 	if singleLogger != nil {
-		singleLogger.log(InfoLevel, format, values...)
+		singleLogger.log(InfoLevel, nil, format, values...)
 	}
 }
 
 func Warning(format string, values ...interface{}) {
 	if singleLogger != nil {
-		singleLogger.log(WarningLevel, format, values...)
+		singleLogger.log(WarningLevel, nil, format, values...)
 	}
 }
 
 func Error(format string, values ...interface{}) {
 	if singleLogger != nil {
-		singleLogger.log(ErrorLevel, format, values...)
+		singleLogger.log(ErrorLevel, nil, format, values...)
+	}
+}
+
+// DebugWriter returns the singleton's DebugWriter, or a writer discarding
+// everything if the singleton is not initialized.
+func DebugWriter() io.Writer {
+	if singleLogger != nil {
+		return singleLogger.DebugWriter()
+	}
+	return ioutil.Discard
+}
+
+func InfoWriter() io.Writer {
+	if singleLogger != nil {
+		return singleLogger.InfoWriter()
+	}
+	return ioutil.Discard
+}
+
+func WarningWriter() io.Writer {
+	if singleLogger != nil {
+		return singleLogger.WarningWriter()
+	}
+	return ioutil.Discard
+}
+
+func ErrorWriter() io.Writer {
+	if singleLogger != nil {
+		return singleLogger.ErrorWriter()
 	}
+	return ioutil.Discard
 }
 
 func StopSingle() {
@@ -1,8 +1,17 @@
 package liblog
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 )
 
@@ -25,6 +34,38 @@ func BenchmarkDebug(b *testing.B) {
 	}
 }
 
+func BenchmarkNoArgs(b *testing.B) {
+	b.Run("fast-path", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			formatMessage("server started", nil)
+		}
+	})
+	b.Run("fmt", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = fmt.Sprintf("server started")
+		}
+	})
+}
+
+func TestLiteralMessage(t *testing.T) {
+	defer quiet()()
+	logger := Init("literal")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+	logger.Info("disk 100% full")
+	logger.Info("%d%% full", 50)
+	logger.StopSync()
+
+	out := buf.String()
+	for _, want := range []string{`"message":"disk 100% full"`, `"message":"50% full"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %s in %s", want, out)
+		}
+	}
+}
+
 func quiet() func() {
 	null, _ := os.Open(os.DevNull)
 	sout := os.Stdout
@@ -39,3 +80,377 @@ func quiet() func() {
 		log.SetOutput(os.Stderr)
 	}
 }
+
+func TestLevelWriterWith(t *testing.T) {
+	defer quiet()()
+	logger := Init("writer")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+
+	w := logger.LevelWriterWith(WarningLevel, map[string]interface{}{"component": "http", "port": 80})
+	fmt.Fprint(w, "GET /")
+	logger.StopSync()
+
+	out := buf.String()
+	if !strings.Contains(out, `"level":"WARNING","message":"GET /"`) {
+		t.Errorf("unexpected level or message: %s", out)
+	}
+	if !strings.HasSuffix(out, `,"component":"http","port":80,"source":"writer"}`+"\n") {
+		t.Errorf("fields missing: %s", out)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	cases := map[string]LogLevel{
+		"DEBUG": DebugLevel, "debug": DebugLevel, "DBG": DebugLevel, "0": DebugLevel,
+		"INFO": InfoLevel, "info": InfoLevel, "1": InfoLevel,
+		"WARNING": WarningLevel, "WARN": WarningLevel, "warn": WarningLevel, "2": WarningLevel,
+		"ERROR": ErrorLevel, "ERR": ErrorLevel, " err ": ErrorLevel, "3": ErrorLevel,
+	}
+	for name, want := range cases {
+		got, err := ParseLevel(name)
+		if err != nil || got != want {
+			t.Errorf("ParseLevel(%q) = %v, %v; want %v", name, got, err, want)
+		}
+	}
+	for _, name := range []string{"", "VERBOSE", "5"} {
+		if got, err := ParseLevel(name); err == nil || got != InfoLevel {
+			t.Errorf("ParseLevel(%q) = %v, %v; want InfoLevel and an error", name, got, err)
+		}
+	}
+}
+
+func TestInitLevelAlias(t *testing.T) {
+	defer quiet()()
+	os.Setenv("LOGLEVEL", "WARN")
+	defer os.Unsetenv("LOGLEVEL")
+	logger := Init("alias")
+	defer logger.StopSync()
+	if logger.Level != WarningLevel {
+		t.Errorf("LOGLEVEL=WARN gave level %v", logger.Level)
+	}
+}
+
+func TestSetLevelString(t *testing.T) {
+	defer quiet()()
+	logger := Init("level")
+	defer logger.StopSync()
+
+	if err := logger.SetLevelString("debug"); err != nil || logger.LevelString() != "DEBUG" {
+		t.Errorf("SetLevelString(debug): %v, level %s", err, logger.LevelString())
+	}
+	if err := logger.SetLevelString("loud"); err == nil || logger.LevelString() != "DEBUG" {
+		t.Errorf("SetLevelString(loud): %v, level %s", err, logger.LevelString())
+	}
+}
+
+type panickingWriter struct{}
+
+func (panickingWriter) Write(p []byte) (int, error) { panic("broken writer") }
+
+func TestPanickingWriter(t *testing.T) {
+	defer quiet()()
+	logger := Init("panic")
+	var buf bytes.Buffer
+	logger.AddWriter(panickingWriter{})
+	logger.AddWriter(&buf)
+
+	logger.Info("first")
+	logger.Info("second")
+	logger.StopSync()
+
+	if out := buf.String(); !strings.Contains(out, `"first"`) || !strings.Contains(out, `"second"`) {
+		t.Errorf("messages lost after a writer panic: %s", out)
+	}
+}
+
+func TestLoggerWriteCloser(t *testing.T) {
+	defer quiet()()
+	logger := Init("writecloser")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+
+	var wc io.WriteCloser = logger
+	fmt.Fprint(wc, "default level")
+	logger.SetWriterLevel(ErrorLevel)
+	fmt.Fprint(wc, "error level")
+	wc.Close()
+
+	out := buf.String()
+	if !strings.Contains(out, `"level":"INFO","message":"default level"`) ||
+		!strings.Contains(out, `"level":"ERROR","message":"error level"`) {
+		t.Errorf("unexpected output: %s", out)
+	}
+}
+
+func TestRender(t *testing.T) {
+	defer quiet()()
+	logger := Init("render")
+	defer logger.StopSync()
+	logger.SetModuleId("r1")
+
+	var msg map[string]interface{}
+	out := logger.Render(DebugLevel, "hello %s", "world")
+	if err := json.Unmarshal(out, &msg); err != nil || out[len(out)-1] != '\n' {
+		t.Fatalf("not a JSON line: %q (%v)", out, err)
+	}
+	want := map[string]interface{}{
+		"level": "DEBUG", "message": "hello world", "service": "render",
+		"service_id": "r1", "src_file": "log_test.go",
+	}
+	for key, value := range want {
+		if msg[key] != value {
+			t.Errorf("%s = %v, want %v", key, msg[key], value)
+		}
+	}
+}
+
+func TestSetModule(t *testing.T) {
+	defer quiet()()
+	logger := Init("before")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 100; i++ {
+			logger.Info("concurrent")
+		}
+		close(done)
+	}()
+	logger.SetModule("after")
+	<-done
+	logger.Info("last")
+	logger.StopSync()
+
+	if out := buf.String(); !strings.Contains(out, `"message":"last","service":"after"`) {
+		t.Errorf("module not changed: %s", out)
+	}
+}
+
+func TestLogWriterCaller(t *testing.T) {
+	defer quiet()()
+	logger := Init("writer")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+
+	logger.InfoLogger("", 0).Print("no caller")
+	w := logger.InfoWriter().(*LogWriter)
+	w.SetCallerSkip(3)
+	log.New(w, "", 0).Printf("with caller")
+	logger.StopSync()
+
+	lines := strings.Split(buf.String(), "\n")
+	if strings.Contains(lines[0], "src_file") || !strings.Contains(lines[0], `"source":"writer"`) {
+		t.Errorf("unexpected source of writer message: %s", lines[0])
+	}
+	if !strings.Contains(lines[1], `"src_file":"log_test.go"`) || strings.Contains(lines[1], `"source"`) {
+		t.Errorf("caller not recorded: %s", lines[1])
+	}
+}
+
+func TestSingletonWriters(t *testing.T) {
+	defer quiet()()
+	StopSyncSingle()
+	if w := ErrorWriter(); w != ioutil.Discard {
+		t.Errorf("uninitialized singleton gave %T", w)
+	}
+
+	var buf bytes.Buffer
+	InitSingleStr("single").AddWriter(&buf)
+	fmt.Fprint(ErrorWriter(), "bridged")
+	StopSyncSingle()
+	if !strings.Contains(buf.String(), `"level":"ERROR","message":"bridged"`) {
+		t.Errorf("unexpected output: %s", buf.String())
+	}
+}
+
+func TestInitSingleFromEnv(t *testing.T) {
+	defer quiet()()
+	StopSyncSingle()
+	os.Setenv("LOGMODULE", "")
+	logger := InitSingleFromEnv()
+	if module := logger.Render(InfoLevel, "x"); !strings.Contains(string(module), `"service":"`+filepath.Base(os.Args[0])+`"`) {
+		t.Errorf("binary name not used: %s", module)
+	}
+	StopSyncSingle()
+
+	os.Setenv("LOGMODULE", "billing")
+	os.Setenv("LOGMODULE_ID", "b-1")
+	defer os.Unsetenv("LOGMODULE")
+	defer os.Unsetenv("LOGMODULE_ID")
+	logger = InitSingleFromEnv()
+	if out := logger.Render(InfoLevel, "x"); !strings.Contains(string(out), `"service":"billing","service_id":"b-1"`) {
+		t.Errorf("env not used: %s", out)
+	}
+	if InitSingleFromEnv() != logger {
+		t.Error("initialized singleton replaced")
+	}
+	StopSyncSingle()
+}
+
+func TestNamed(t *testing.T) {
+	defer quiet()()
+	// synchronous so that SetLevel cannot overtake the queued messages
+	logger := Init("named", WithSynchronous())
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+
+	jwt := logger.Named("api").Named("auth").Named("jwt")
+	jwt.Info("child")
+	logger.Info("root")
+	jwt.SetLevel(ErrorLevel)
+	logger.Info("filtered by the shared level")
+	logger.StopSync()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines: %s", len(lines), buf.String())
+	}
+	if !strings.Contains(lines[0], `"service":"named","logger":"api.auth.jwt"`) {
+		t.Errorf("name missing: %s", lines[0])
+	}
+	if strings.Contains(lines[1], `"logger"`) {
+		t.Errorf("root logger has a name: %s", lines[1])
+	}
+}
+
+func TestLogWriterSkipsBlankWrites(t *testing.T) {
+	defer quiet()()
+	logger := Init("writer")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+
+	w := logger.InfoWriter()
+	for _, p := range []string{"", "\n", " \t\r\n", "text\n"} {
+		if n, err := w.Write([]byte(p)); n != len(p) || err != nil {
+			t.Errorf("Write(%q) = %d, %v", p, n, err)
+		}
+	}
+	logger.StopSync()
+
+	if n := strings.Count(buf.String(), "\n"); n != 1 {
+		t.Errorf("%d messages logged, want 1: %s", n, buf.String())
+	}
+}
+
+func TestLogWriterSplitLines(t *testing.T) {
+	defer quiet()()
+	logger := Init("writer")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+
+	w := logger.ErrorWriter().(*LogWriter)
+	w.SetSplitLines(true)
+	for _, p := range []string{"panic: boom\n\ngoroutine 1\r\n\tmain.go", ":12\n100% tail"} {
+		if n, err := w.Write([]byte(p)); n != len(p) || err != nil {
+			t.Errorf("Write(%q) = %d, %v", p, n, err)
+		}
+	}
+	w.Flush()
+	logger.StopSync()
+
+	want := []string{"panic: boom", "goroutine 1", `\tmain.go:12`, "100% tail"}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines: %s", len(lines), buf.String())
+	}
+	for i, line := range lines {
+		if !strings.Contains(line, `"message":"`+want[i]+`"`) {
+			t.Errorf("line %d: %s, want %q", i, line, want[i])
+		}
+	}
+}
+
+func TestWriters(t *testing.T) {
+	defer quiet()()
+	logger := Init("writers")
+	defer logger.StopSync()
+	var first, second bytes.Buffer
+	logger.AddWriter(&first)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			logger.Info("concurrent")
+		}
+	}()
+	logger.AddWriter(&second)
+	<-done
+
+	writers := logger.Writers()
+	if len(writers) != 2 || writers[0] != &first || writers[1] != &second {
+		t.Fatalf("unexpected writers %v", writers)
+	}
+	writers[0] = nil
+	if logger.Writers()[0] != &first {
+		t.Errorf("Writers exposes the internal slice")
+	}
+}
+
+func TestMaxMessageBytes(t *testing.T) {
+	defer quiet()()
+	logger := Init("truncate")
+	defer logger.StopSync()
+	logger.SetMaxMessageBytes(10)
+
+	cases := map[string]string{
+		"short":        "short",
+		"exactly10!":   "exactly10!",
+		"ab€€€€":       "ab€€...(truncated)",
+		"abcdefghi€xy": "abcdefghi...(truncated)",
+	}
+	for text, want := range cases {
+		var msg map[string]interface{}
+		json.Unmarshal(logger.Render(InfoLevel, "%s", text), &msg)
+		if msg["message"] != want || (want != text) != (msg["truncated"] == true) {
+			t.Errorf("%q logged as %v", text, msg)
+		}
+	}
+}
+
+func TestSingletonCaller(t *testing.T) {
+	defer quiet()()
+	var buf bytes.Buffer
+	logger := InitSingleStr("single")
+	logger.SetLevel(DebugLevel)
+	logger.AddWriter(&buf)
+	_, _, line, _ := runtime.Caller(0)
+	Debug("debug")
+	Info("info")
+	Warning("warning")
+	Error("error")
+	InfoCtx(context.Background(), "ctx")
+	StopSyncSingle()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("got %d lines: %s", len(lines), buf.String())
+	}
+	for i, l := range lines {
+		if want := fmt.Sprintf(`"src_file":"log_test.go","src_line":%d`, line+1+i); !strings.Contains(l, want) {
+			t.Errorf("line %d: %s, want %s", i, l, want)
+		}
+	}
+}
+
+func TestLogAt(t *testing.T) {
+	defer quiet()()
+	logger := Init("at")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+
+	logger.LogAt("/gen/api.pb.go", 1234, WarningLevel, "generated")
+	_, _, line, _ := runtime.Caller(0)
+	logger.LogAt("", 99, InfoLevel, "fallback")
+	logger.StopSync()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if !strings.HasSuffix(lines[0], `"src_file":"api.pb.go","src_line":1234}`) {
+		t.Errorf("explicit location not used: %s", lines[0])
+	}
+	if want := fmt.Sprintf(`"src_file":"log_test.go","src_line":%d}`, line+1); !strings.HasSuffix(lines[1], want) {
+		t.Errorf("caller location not used: %s", lines[1])
+	}
+}
@@ -0,0 +1,173 @@
+// Package logtest captures what a liblog logger writes so that tests can
+// make assertions about it.
+package logtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/wimark/liblog"
+)
+
+// Capture is a writer keeping the JSON records written to it.
+type Capture struct {
+	mu      sync.Mutex
+	records []record
+}
+
+type record struct {
+	level   string
+	message string
+	values  []string
+	line    string
+}
+
+// headerKeys are the keys JSONFormatter writes for every record, which
+// Logged does not look into.
+var headerKeys = map[string]bool{
+	"timestamp": true, "level": true, "severity": true, "levelno": true,
+	"message": true, "service": true, "service_id": true, "logger": true,
+	"source": true, "caller": true, "src_file": true, "src_line": true,
+	"src_func": true, "goid": true,
+}
+
+// New returns a synchronous logger at Debug level writing to a Capture
+// only, so that everything logged can be checked as soon as the log call
+// returns.
+func New(module string) (*liblog.Logger, *Capture) {
+	capture := new(Capture)
+	logger := liblog.Init(module, liblog.WithSynchronous(), liblog.WithOutput(ioutil.Discard), liblog.WithFormatter(liblog.JSONFormatter{}))
+	logger.SetLevel(liblog.DebugLevel)
+	logger.AddWriter(capture)
+	return logger, capture
+}
+
+func (c *Capture) Write(p []byte) (int, error) {
+	var header struct {
+		Level   string `json:"level"`
+		Message string `json:"message"`
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(p, &header); err != nil {
+		return 0, err
+	}
+	if err := json.Unmarshal(p, &fields); err != nil {
+		return 0, err
+	}
+	r := record{level: header.Level, message: header.Message, line: strings.TrimRight(string(p), "\n")}
+	for key, value := range fields {
+		if !headerKeys[key] {
+			r.values = appendValues(r.values, value)
+		}
+	}
+	c.mu.Lock()
+	c.records = append(c.records, r)
+	c.mu.Unlock()
+	return len(p), nil
+}
+
+// appendValues appends the values in raw as text: strings unquoted, other
+// values as JSON, and groups and arrays value by value.
+func appendValues(values []string, raw json.RawMessage) []string {
+	var object map[string]json.RawMessage
+	var array []json.RawMessage
+	var str string
+	switch {
+	case json.Unmarshal(raw, &object) == nil && object != nil:
+		for _, value := range object {
+			values = appendValues(values, value)
+		}
+	case json.Unmarshal(raw, &array) == nil && array != nil:
+		for _, value := range array {
+			values = appendValues(values, value)
+		}
+	case json.Unmarshal(raw, &str) == nil:
+		values = append(values, str)
+	default:
+		values = append(values, string(raw))
+	}
+	return values
+}
+
+// Lines returns the captured records.
+func (c *Capture) Lines() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	lines := make([]string, len(c.records))
+	for i, r := range c.records {
+		lines[i] = r.line
+	}
+	return lines
+}
+
+// Reset forgets the captured records.
+func (c *Capture) Reset() {
+	c.mu.Lock()
+	c.records = nil
+	c.mu.Unlock()
+}
+
+// Logged tells whether a record at level has a message or a field value
+// containing substr. Keys are not matched, nor are the time, module and
+// source written for every record: "tenant" does not match a field
+// tenant=7, while "7" does. String values are matched unquoted, others as
+// JSON, e.g. "true" or "1.5", and the values of groups one by one.
+func (c *Capture) Logged(level liblog.LogLevel, substr string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	name := level.String()
+	for _, r := range c.records {
+		if r.level == name && r.contains(substr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r record) contains(substr string) bool {
+	if strings.Contains(r.message, substr) {
+		return true
+	}
+	for _, value := range r.values {
+		if strings.Contains(value, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// AssertLogged fails tb, listing the captured records, unless a record at
+// level contains substr as described for Logged.
+func (c *Capture) AssertLogged(tb testing.TB, level liblog.LogLevel, substr string) {
+	tb.Helper()
+	if !c.Logged(level, substr) {
+		tb.Errorf("no %s record containing %q%s", level, substr, c.dump())
+	}
+}
+
+// AssertNotLogged fails tb, listing the captured records, if a record at
+// level contains substr.
+func (c *Capture) AssertNotLogged(tb testing.TB, level liblog.LogLevel, substr string) {
+	tb.Helper()
+	if c.Logged(level, substr) {
+		tb.Errorf("unexpected %s record containing %q%s", level, substr, c.dump())
+	}
+}
+
+func (c *Capture) dump() string {
+	lines := c.Lines()
+	if len(lines) == 0 {
+		return "; nothing was logged"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "; %d records logged:", len(lines))
+	for _, line := range lines {
+		b.WriteString("\n\t")
+		b.WriteString(line)
+	}
+	return b.String()
+}
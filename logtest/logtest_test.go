@@ -0,0 +1,51 @@
+package logtest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/wimark/liblog"
+)
+
+// recorder is a testing.TB keeping the reported failures.
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func TestAssertions(t *testing.T) {
+	logger, capture := New("logtest")
+	logger.Error("connection to %q lost", "db")
+	logger.With("tenant", 7).Group("http").With("path", "/users").Debug("retrying")
+
+	capture.AssertLogged(t, liblog.ErrorLevel, `connection to "db"`)
+	capture.AssertLogged(t, liblog.DebugLevel, "7")
+	capture.AssertLogged(t, liblog.DebugLevel, "/users")
+	capture.AssertNotLogged(t, liblog.DebugLevel, "tenant")
+	capture.AssertNotLogged(t, liblog.DebugLevel, "src_file")
+	capture.AssertNotLogged(t, liblog.DebugLevel, "logtest")
+	capture.AssertNotLogged(t, liblog.InfoLevel, "connection")
+
+	r := &recorder{TB: t}
+	capture.AssertLogged(r, liblog.WarningLevel, "connection")
+	capture.AssertNotLogged(r, liblog.ErrorLevel, "lost")
+	if len(r.failures) != 2 {
+		t.Fatalf("got failures %q", r.failures)
+	}
+	if !strings.HasPrefix(r.failures[0], `no WARNING record containing "connection"; 2 records logged:`+"\n\t{") {
+		t.Errorf("unexpected failure: %s", r.failures[0])
+	}
+
+	capture.Reset()
+	logger.StopSync()
+	if len(capture.Lines()) != 0 {
+		t.Errorf("records left after Reset")
+	}
+}
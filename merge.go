@@ -0,0 +1,55 @@
+package liblog
+
+// SetDefaultFields sets fields, built from alternating keys and values like
+// With, written with every message of logger and of the loggers sharing
+// its pipeline. Each call replaces the previous defaults.
+func (logger *Logger) SetDefaultFields(keyvals ...interface{}) {
+	fields := keyvalFields(keyvals)
+	logger.mu.Lock()
+	logger.defaults = fields
+	logger.mu.Unlock()
+}
+
+// mergeFields adds the default fields and drops repeated keys so that a key
+// is written once, with the value of its last occurrence at the position
+// of its first. Fields come in precedence order: defaults, With fields,
+// context fields and then the message's own.
+func (logger *Logger) mergeFields(msg *LogMsg) {
+	logger.mu.RLock()
+	defaults := logger.defaults
+	logger.mu.RUnlock()
+	if len(defaults) > 0 {
+		fields := make([]Field, 0, len(defaults)+len(msg.Fields))
+		fields = append(fields, defaults...)
+		msg.Fields = append(fields, msg.Fields...)
+	}
+	if !hasDuplicateKeys(msg.Fields) {
+		return
+	}
+	index := make(map[string]int, len(msg.Fields))
+	fields := make([]Field, 0, len(msg.Fields))
+	for _, field := range msg.Fields {
+		if i, ok := index[field.Key]; ok {
+			fields[i].Value = field.Value
+			continue
+		}
+		index[field.Key] = len(fields)
+		fields = append(fields, field)
+	}
+	msg.Fields = fields
+}
+
+// hasDuplicateKeys saves building a map for the usual few unique fields.
+func hasDuplicateKeys(fields []Field) bool {
+	if len(fields) > 16 {
+		return true
+	}
+	for i := 1; i < len(fields); i++ {
+		for j := 0; j < i; j++ {
+			if fields[i].Key == fields[j].Key {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package liblog
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestMergeFields(t *testing.T) {
+	defer quiet()()
+	logger := Init("merge")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+
+	logger.SetDefaultFields("k", "default", "env", "prod")
+	ctx := ContextWith(context.Background(), Any("k", "context"))
+	with := logger.With("k", "with", "region", "eu")
+	with.LogKVCtx(ctx, InfoLevel, "all", "k", "message")
+	with.InfoCtx(ctx, "no message field")
+	with.Info("with only")
+	logger.Info("default only")
+	logger.StopSync()
+
+	want := []string{
+		`"k":"message","env":"prod","region":"eu"}`,
+		`"k":"context","env":"prod","region":"eu"}`,
+		`"k":"with","env":"prod","region":"eu"}`,
+		`"k":"default","env":"prod"}`,
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines: %s", len(lines), buf.String())
+	}
+	for i, line := range lines {
+		if !strings.HasSuffix(line, want[i]) || strings.Count(line, `"k":`) != 1 {
+			t.Errorf("line %d: %s, want suffix %s", i, line, want[i])
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package liblog
+
+import (
+	"errors"
+	"strings"
+	"sync"
+)
+
+var moduleValidator struct {
+	sync.RWMutex
+	validate func(module string) (string, error)
+}
+
+// SetModuleValidator makes Init, InitValidated and SetModule pass module
+// names, and Event its event types, through validate, which returns the
+// name to use or an error for a name that cannot be fixed, e.g.
+// NormalizeModuleName. nil, the default, accepts every name as is.
+//
+// Init has no way to report an error: it logs it as an internal error
+// (see SetInternalErrorWriter) and keeps the name as given. SetModule
+// does the same but keeps the current name. InitValidated returns the
+// error instead.
+func SetModuleValidator(validate func(module string) (string, error)) {
+	moduleValidator.Lock()
+	moduleValidator.validate = validate
+	moduleValidator.Unlock()
+}
+
+func validateModule(module string) (string, error) {
+	moduleValidator.RLock()
+	validate := moduleValidator.validate
+	moduleValidator.RUnlock()
+	if validate == nil {
+		return module, nil
+	}
+	return validate(module)
+}
+
+// NormalizeModuleName lowercases module and replaces every character
+// other than a-z, 0-9, '_' and '-' with '_'. It fails for an empty name.
+func NormalizeModuleName(module string) (string, error) {
+	if module == "" {
+		return "", errors.New("liblog: empty module name")
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		}
+		return '_'
+	}, module), nil
+}
+
+// InitValidated is Init returning the error of the module validator set
+// with SetModuleValidator instead of logging it, in which case no logger
+// is started.
+func InitValidated(module string, options ...Option) (*Logger, error) {
+	valid, err := validateModule(module)
+	if err != nil {
+		return nil, err
+	}
+	return initLogger(valid, options...), nil
+}
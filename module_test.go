@@ -0,0 +1,38 @@
+package liblog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestModuleValidator(t *testing.T) {
+	defer quiet()()
+	var diagnostics bytes.Buffer
+	SetInternalErrorWriter(&diagnostics)
+	defer SetInternalErrorWriter(nil)
+	SetModuleValidator(NormalizeModuleName)
+	defer SetModuleValidator(nil)
+
+	logger := Init("Billing API")
+	if logger.module != "billing_api" {
+		t.Errorf("module not normalized: %q", logger.module)
+	}
+	logger.SetModule("")
+	if logger.module != "billing_api" {
+		t.Errorf("invalid module applied: %q", logger.module)
+	}
+	if !strings.Contains(diagnostics.String(), `invalid module name ""`) {
+		t.Errorf("invalid module not reported: %q", diagnostics.String())
+	}
+	logger.StopSync()
+
+	if l, err := InitValidated(""); l != nil || err == nil {
+		t.Errorf("InitValidated accepted an empty module")
+	}
+	l, err := InitValidated("Ok-1")
+	if err != nil || l.module != "ok-1" {
+		t.Errorf("InitValidated returned %v, %v", l, err)
+	}
+	l.StopSync()
+}
@@ -0,0 +1,336 @@
+// Package msgpack provides a liblog formatter writing MessagePack records
+// instead of JSON lines.
+package msgpack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/wimark/liblog"
+)
+
+// Formatter encodes every message as a MessagePack map with the same keys
+// and values the JSON formatter writes. The timestamp uses the standard
+// timestamp extension type. Records are self-delimiting, so no line
+// terminator is written.
+type Formatter struct{}
+
+func (Formatter) Format(buf *bytes.Buffer, msg *liblog.LogMsg, opts liblog.FormatOptions) error {
+	size := 4 + len(msg.Fields)
+	if msg.Message == "" && opts.OmitEmptyMessage {
+		size--
+	}
+	if msg.Module == "" {
+		size--
+	}
+	if msg.ModuleId != "" {
+		size++
+	}
+	if msg.Logger != "" {
+		size++
+	}
+	if opts.NestedSource || opts.CallerField {
+		if msg.SrcFile != "" {
+			size++
+		}
+	} else {
+		if msg.SrcFile != "" {
+			size++
+		}
+		if msg.SrcLine != 0 {
+			size++
+		}
+		if msg.SrcFunc != "" {
+			size++
+		}
+	}
+	if msg.GoroutineId != 0 {
+		size++
+	}
+	label, number, severity := opts.SeverityOf(msg.Level)
+	if severity {
+		size++
+	}
+	if opts.LevelNumber {
+		size++
+	}
+
+	writeMapHeader(buf, size)
+	writeString(buf, "timestamp")
+	writeTime(buf, msg.Timestamp)
+	writeString(buf, "level")
+	writeString(buf, label)
+	if severity {
+		writeString(buf, "severity")
+		writeInt(buf, int64(number))
+	}
+	if opts.LevelNumber {
+		writeString(buf, "levelno")
+		writeInt(buf, int64(msg.Level))
+	}
+	if msg.Message != "" || !opts.OmitEmptyMessage {
+		writeString(buf, "message")
+		writeString(buf, msg.Message)
+	}
+	if msg.Module != "" {
+		writeString(buf, "service")
+		writeString(buf, msg.Module)
+	}
+	if msg.ModuleId != "" {
+		writeString(buf, "service_id")
+		writeString(buf, msg.ModuleId)
+	}
+	if msg.Logger != "" {
+		writeString(buf, "logger")
+		writeString(buf, msg.Logger)
+	}
+	if opts.NestedSource {
+		if msg.SrcFile != "" {
+			writeString(buf, "source")
+			if msg.SrcFunc != "" {
+				writeMapHeader(buf, 3)
+			} else {
+				writeMapHeader(buf, 2)
+			}
+			writeString(buf, "file")
+			writeString(buf, msg.SrcFile)
+			writeString(buf, "line")
+			writeInt(buf, int64(msg.SrcLine))
+			if msg.SrcFunc != "" {
+				writeString(buf, "function")
+				writeString(buf, msg.SrcFunc)
+			}
+		}
+	} else if opts.CallerField {
+		if msg.SrcFile != "" {
+			writeString(buf, "caller")
+			writeString(buf, msg.SrcFile+":"+strconv.Itoa(msg.SrcLine))
+		}
+	} else {
+		if msg.SrcFile != "" {
+			writeString(buf, "src_file")
+			writeString(buf, msg.SrcFile)
+		}
+		if msg.SrcLine != 0 {
+			writeString(buf, "src_line")
+			writeInt(buf, int64(msg.SrcLine))
+		}
+		if msg.SrcFunc != "" {
+			writeString(buf, "src_func")
+			writeString(buf, msg.SrcFunc)
+		}
+	}
+	if msg.GoroutineId != 0 {
+		writeString(buf, "goid")
+		writeUint(buf, msg.GoroutineId)
+	}
+	for _, field := range msg.Fields {
+		writeString(buf, field.Key)
+		writeValue(buf, field.Value)
+	}
+	return nil
+}
+
+func writeValue(buf *bytes.Buffer, value interface{}) {
+	switch v := value.(type) {
+	case nil:
+		buf.WriteByte(0xc0)
+	case bool:
+		if v {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case string:
+		writeString(buf, v)
+	case []byte:
+		writeBinary(buf, v)
+	case int:
+		writeInt(buf, int64(v))
+	case int8:
+		writeInt(buf, int64(v))
+	case int16:
+		writeInt(buf, int64(v))
+	case int32:
+		writeInt(buf, int64(v))
+	case int64:
+		writeInt(buf, v)
+	case uint:
+		writeUint(buf, uint64(v))
+	case uint8:
+		writeUint(buf, uint64(v))
+	case uint16:
+		writeUint(buf, uint64(v))
+	case uint32:
+		writeUint(buf, uint64(v))
+	case uint64:
+		writeUint(buf, v)
+	case float32:
+		buf.WriteByte(0xca)
+		write32(buf, math.Float32bits(v))
+	case float64:
+		buf.WriteByte(0xcb)
+		write64(buf, math.Float64bits(v))
+	case time.Time:
+		writeTime(buf, v)
+	case []interface{}:
+		writeArrayHeader(buf, len(v))
+		for _, item := range v {
+			writeValue(buf, item)
+		}
+	case liblog.Fields:
+		writeMapHeader(buf, len(v))
+		for _, field := range v {
+			writeString(buf, field.Key)
+			writeValue(buf, field.Value)
+		}
+	case map[string]interface{}:
+		writeMapHeader(buf, len(v))
+		for key, item := range v {
+			writeString(buf, key)
+			writeValue(buf, item)
+		}
+	case error:
+		writeString(buf, v.Error())
+	default:
+		// anything else is encoded like its JSON representation
+		data, err := json.Marshal(v)
+		if err != nil {
+			writeString(buf, fmt.Sprint(v))
+			return
+		}
+		var decoded interface{}
+		json.Unmarshal(data, &decoded)
+		writeValue(buf, decoded)
+	}
+}
+
+func writeString(buf *bytes.Buffer, s string) {
+	switch n := len(s); {
+	case n < 32:
+		buf.WriteByte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		buf.WriteByte(0xd9)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xda)
+		write16(buf, uint16(n))
+	default:
+		buf.WriteByte(0xdb)
+		write32(buf, uint32(n))
+	}
+	buf.WriteString(s)
+}
+
+func writeBinary(buf *bytes.Buffer, b []byte) {
+	switch n := len(b); {
+	case n <= math.MaxUint8:
+		buf.WriteByte(0xc4)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xc5)
+		write16(buf, uint16(n))
+	default:
+		buf.WriteByte(0xc6)
+		write32(buf, uint32(n))
+	}
+	buf.Write(b)
+}
+
+func writeInt(buf *bytes.Buffer, v int64) {
+	switch {
+	case v >= 0:
+		writeUint(buf, uint64(v))
+	case v >= -32:
+		buf.WriteByte(byte(v))
+	case v >= math.MinInt8:
+		buf.WriteByte(0xd0)
+		buf.WriteByte(byte(v))
+	case v >= math.MinInt16:
+		buf.WriteByte(0xd1)
+		write16(buf, uint16(v))
+	case v >= math.MinInt32:
+		buf.WriteByte(0xd2)
+		write32(buf, uint32(v))
+	default:
+		buf.WriteByte(0xd3)
+		write64(buf, uint64(v))
+	}
+}
+
+func writeUint(buf *bytes.Buffer, v uint64) {
+	switch {
+	case v < 128:
+		buf.WriteByte(byte(v))
+	case v <= math.MaxUint8:
+		buf.WriteByte(0xcc)
+		buf.WriteByte(byte(v))
+	case v <= math.MaxUint16:
+		buf.WriteByte(0xcd)
+		write16(buf, uint16(v))
+	case v <= math.MaxUint32:
+		buf.WriteByte(0xce)
+		write32(buf, uint32(v))
+	default:
+		buf.WriteByte(0xcf)
+		write64(buf, v)
+	}
+}
+
+func writeMapHeader(buf *bytes.Buffer, n int) {
+	switch {
+	case n < 16:
+		buf.WriteByte(0x80 | byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xde)
+		write16(buf, uint16(n))
+	default:
+		buf.WriteByte(0xdf)
+		write32(buf, uint32(n))
+	}
+}
+
+func writeArrayHeader(buf *bytes.Buffer, n int) {
+	switch {
+	case n < 16:
+		buf.WriteByte(0x90 | byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xdc)
+		write16(buf, uint16(n))
+	default:
+		buf.WriteByte(0xdd)
+		write32(buf, uint32(n))
+	}
+}
+
+// writeTime uses the 96-bit form of the timestamp extension (type -1).
+func writeTime(buf *bytes.Buffer, t time.Time) {
+	buf.WriteByte(0xc7)
+	buf.WriteByte(12)
+	buf.WriteByte(0xff)
+	write32(buf, uint32(t.Nanosecond()))
+	write64(buf, uint64(t.Unix()))
+}
+
+func write16(buf *bytes.Buffer, v uint16) {
+	var b [2]byte
+	binary.BigEndian.PutUint16(b[:], v)
+	buf.Write(b[:])
+}
+
+func write32(buf *bytes.Buffer, v uint32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	buf.Write(b[:])
+}
+
+func write64(buf *bytes.Buffer, v uint64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], v)
+	buf.Write(b[:])
+}
@@ -0,0 +1,243 @@
+package msgpack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/wimark/liblog"
+)
+
+// decode reads back the subset of MessagePack the formatter produces.
+func decode(r *bytes.Reader) (interface{}, error) {
+	tag, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case tag < 0x80:
+		return int64(tag), nil
+	case tag >= 0xe0:
+		return int64(int8(tag)), nil
+	case tag&0xf0 == 0x80:
+		return decodeMap(r, int(tag&0x0f))
+	case tag&0xf0 == 0x90:
+		return decodeArray(r, int(tag&0x0f))
+	case tag&0xe0 == 0xa0:
+		return readString(r, int(tag&0x1f))
+	}
+	switch tag {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4:
+		n, _ := r.ReadByte()
+		b := make([]byte, n)
+		_, err := r.Read(b)
+		return b, err
+	case 0xc7:
+		n, _ := r.ReadByte()
+		ext, _ := r.ReadByte()
+		if n != 12 || ext != 0xff {
+			return nil, fmt.Errorf("unexpected ext %d/%d", n, ext)
+		}
+		nsec, sec := readN(r, 4), readN(r, 8)
+		return time.Unix(int64(sec), int64(nsec)), nil
+	case 0xcb:
+		return math.Float64frombits(readN(r, 8)), nil
+	case 0xcc:
+		return int64(readN(r, 1)), nil
+	case 0xcd:
+		return int64(readN(r, 2)), nil
+	case 0xce:
+		return int64(readN(r, 4)), nil
+	case 0xcf:
+		return int64(readN(r, 8)), nil
+	case 0xd0:
+		return int64(int8(readN(r, 1))), nil
+	case 0xd1:
+		return int64(int16(readN(r, 2))), nil
+	case 0xd2:
+		return int64(int32(readN(r, 4))), nil
+	case 0xd3:
+		return int64(readN(r, 8)), nil
+	case 0xd9:
+		return readString(r, int(readN(r, 1)))
+	case 0xda:
+		return readString(r, int(readN(r, 2)))
+	case 0xde:
+		return decodeMap(r, int(readN(r, 2)))
+	}
+	return nil, fmt.Errorf("unsupported tag %#x", tag)
+}
+
+func readN(r *bytes.Reader, n int) uint64 {
+	b := make([]byte, 8)
+	r.Read(b[8-n:])
+	return binary.BigEndian.Uint64(b)
+}
+
+func readString(r *bytes.Reader, n int) (string, error) {
+	b := make([]byte, n)
+	_, err := r.Read(b)
+	return string(b), err
+}
+
+func decodeMap(r *bytes.Reader, n int) (interface{}, error) {
+	m := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		key, err := decode(r)
+		if err != nil {
+			return nil, err
+		}
+		if m[key.(string)], err = decode(r); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func decodeArray(r *bytes.Reader, n int) (interface{}, error) {
+	a := make([]interface{}, n)
+	for i := range a {
+		var err error
+		if a[i], err = decode(r); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+func TestRoundTrip(t *testing.T) {
+	now := time.Now()
+	msg := &liblog.LogMsg{
+		Timestamp: now,
+		Level:     liblog.WarningLevel,
+		Message:   "disk almost full",
+		Module:    "storage",
+		SrcFile:   "disk.go",
+		SrcLine:   42,
+		Fields: []liblog.Field{
+			liblog.Any("used", 0.93),
+			liblog.Any("free", -1200),
+			liblog.Any("big", uint64(1)<<40),
+			liblog.Any("ok", false),
+			liblog.Any("none", nil),
+			liblog.Any("raw", []byte{1, 2, 3}),
+			liblog.Any("err", errors.New("quota")),
+			liblog.Any("tags", []string{"a", "b"}),
+			liblog.Any("long", string(bytes.Repeat([]byte("x"), 300))),
+		},
+	}
+	var buf bytes.Buffer
+	if err := (Formatter{}).Format(&buf, msg, liblog.FormatOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	r := bytes.NewReader(buf.Bytes())
+	decoded, err := decode(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Len() != 0 {
+		t.Errorf("%d bytes left after the record", r.Len())
+	}
+
+	got := decoded.(map[string]interface{})
+	if ts := got["timestamp"].(time.Time); !ts.Equal(now) {
+		t.Errorf("timestamp %v, want %v", ts, now)
+	}
+	delete(got, "timestamp")
+	want := map[string]interface{}{
+		"level": "WARNING", "message": "disk almost full", "service": "storage",
+		"src_file": "disk.go", "src_line": int64(42),
+		"used": 0.93, "free": int64(-1200), "big": int64(1 << 40), "ok": false, "none": nil,
+		"raw": []byte{1, 2, 3}, "err": "quota", "tags": []interface{}{"a", "b"},
+		"long": string(bytes.Repeat([]byte("x"), 300)),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decoded\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestOmitEmptyMessage(t *testing.T) {
+	msg := &liblog.LogMsg{Level: liblog.InfoLevel, Module: "event", Fields: []liblog.Field{liblog.Any("event", "login")}}
+	var buf bytes.Buffer
+	(Formatter{}).Format(&buf, msg, liblog.FormatOptions{OmitEmptyMessage: true})
+	decoded, err := decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := decoded.(map[string]interface{})
+	if _, ok := got["message"]; ok || got["event"] != "login" {
+		t.Errorf("unexpected record %v", got)
+	}
+}
+
+func TestEmptyModule(t *testing.T) {
+	msg := &liblog.LogMsg{Level: liblog.InfoLevel, Message: "hi", Fields: []liblog.Field{liblog.Any("k", 1)}}
+	var buf bytes.Buffer
+	(Formatter{}).Format(&buf, msg, liblog.FormatOptions{})
+	decoded, err := decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := decoded.(map[string]interface{})
+	if _, ok := got["service"]; ok || got["message"] != "hi" {
+		t.Errorf("unexpected record %v", got)
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	msg := &liblog.LogMsg{Level: liblog.ErrorLevel, Module: "sev"}
+	var buf bytes.Buffer
+	(Formatter{}).Format(&buf, msg, liblog.FormatOptions{Severity: liblog.GCPSeverity})
+	r := bytes.NewReader(buf.Bytes())
+	decoded, err := decode(r)
+	if err != nil || r.Len() != 0 {
+		t.Fatalf("bad record: %v, %d bytes left", err, r.Len())
+	}
+	got := decoded.(map[string]interface{})
+	if got["level"] != "ERROR" || fmt.Sprint(got["severity"]) != "500" {
+		t.Errorf("unexpected record %v", got)
+	}
+}
+
+func TestLoggerOutput(t *testing.T) {
+	null, _ := os.Open(os.DevNull)
+	stdout := os.Stdout
+	os.Stdout = null
+	defer func() {
+		os.Stdout = stdout
+		null.Close()
+	}()
+
+	logger := liblog.Init("msgpack", liblog.WithSynchronous())
+	logger.SetFormatter(Formatter{})
+	logger.SetCallerSingleField(true)
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+	logger.Warning("first")
+	logger.Warning("second")
+	logger.StopSync()
+
+	r := bytes.NewReader(buf.Bytes())
+	for _, want := range []string{"first", "second"} {
+		decoded, err := decode(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg := decoded.(map[string]interface{})
+		if msg["message"] != want || msg["caller"] == nil {
+			t.Errorf("unexpected record %v", msg)
+		}
+	}
+}
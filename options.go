@@ -0,0 +1,42 @@
+package liblog
+
+import "io"
+
+// Option configures a logger created by Init. Options take precedence
+// over the environment variables read by Init.
+type Option func(*Logger)
+
+// WithSynchronous makes the logger write every message from the logging
+// call itself instead of a background goroutine, so Init starts no
+// goroutines. It suits js/wasm and other environments where goroutines
+// and channel timing are a problem; calls block for the duration of the
+// writes.
+func WithSynchronous() Option {
+	return func(logger *Logger) {
+		logger.synchronous = true
+	}
+}
+
+// WithFormatter sets the formatter, overriding LOGFORMAT.
+func WithFormatter(formatter Formatter) Option {
+	return func(logger *Logger) {
+		logger.formatter = formatter
+	}
+}
+
+// WithOutput makes the logger write to w instead of stdout, overriding
+// LOGOUTPUT. Writers added with AddWriter still get every message.
+func WithOutput(w io.Writer) Option {
+	return func(logger *Logger) {
+		logger.stdout = w
+	}
+}
+
+// WithErrorOutput makes the logger write the records meant for standard
+// error, with SetSplitStreams or SetFailsafeStderr, to w instead of the
+// os.Stderr of the time Init is called.
+func WithErrorOutput(w io.Writer) Option {
+	return func(logger *Logger) {
+		logger.stderr = w
+	}
+}
@@ -0,0 +1,25 @@
+package liblog
+
+import (
+	"bytes"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestWithSynchronous(t *testing.T) {
+	defer quiet()()
+	goroutines := runtime.NumGoroutine()
+	logger := Init("sync", WithSynchronous())
+	if n := runtime.NumGoroutine(); n != goroutines {
+		t.Errorf("Init started %d goroutines", n-goroutines)
+	}
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+
+	logger.Info("written inline")
+	if !strings.Contains(buf.String(), `"message":"written inline"`) {
+		t.Errorf("message not written before the call returned: %q", buf.String())
+	}
+	logger.StopSync()
+}
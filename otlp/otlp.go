@@ -0,0 +1,392 @@
+// Package otlp exports liblog records to an OpenTelemetry collector using
+// OTLP/HTTP with JSON encoding, without depending on the OpenTelemetry SDK.
+package otlp
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Config configures an Exporter. Zero values select the defaults.
+type Config struct {
+	// Endpoint is the full URL of the logs endpoint, e.g.
+	// "http://localhost:4318/v1/logs".
+	Endpoint string
+	Headers  map[string]string
+	Client   *http.Client
+	// BatchSize is the largest number of records sent in one request,
+	// 512 by default.
+	BatchSize int
+	// FlushInterval is how long records wait for a batch to fill up,
+	// one second by default.
+	FlushInterval time.Duration
+	// QueueSize is the number of records waiting to be sent above which
+	// new ones are dropped, 4096 by default.
+	QueueSize int
+	// MaxRetries is how many times a failed request is retried with
+	// exponential backoff, 3 by default; negative disables retries.
+	MaxRetries int
+}
+
+// Exporter is an io.Writer taking the records of the liblog JSON formatter,
+// e.g. added with AddWriter, and sending them in batches from a background
+// goroutine. Write never blocks on the network: when the queue is full,
+// records are dropped and counted.
+type Exporter struct {
+	dropped uint64
+
+	cfg     Config
+	queue   chan record
+	flush   chan chan struct{}
+	quit    chan struct{}
+	done    chan struct{}
+	closing sync.Once
+}
+
+// record is a decoded JSON record with the time the exporter got it.
+type record struct {
+	fields   map[string]interface{}
+	observed time.Time
+}
+
+var levelSeverity = map[string]int{
+	"DEBUG":   5,
+	"INFO":    9,
+	"WARNING": 13,
+	"ERROR":   17,
+	"FATAL":   21,
+}
+
+// New returns an Exporter sending to cfg.Endpoint, with its background
+// goroutine started. Close stops it once the queued records are sent.
+func New(cfg Config) *Exporter {
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 512
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = time.Second
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 4096
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 3
+	}
+	e := &Exporter{
+		cfg:   cfg,
+		queue: make(chan record, cfg.QueueSize),
+		flush: make(chan chan struct{}),
+		quit:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+// Write queues one JSON record. It fails if p is not a JSON object.
+func (e *Exporter) Write(p []byte) (n int, err error) {
+	decoder := json.NewDecoder(bytes.NewReader(p))
+	decoder.UseNumber()
+	rec := record{observed: time.Now()}
+	if err := decoder.Decode(&rec.fields); err != nil {
+		return 0, err
+	}
+	if rec.fields == nil {
+		return 0, errors.New("otlp: record is not a JSON object")
+	}
+	select {
+	case <-e.quit:
+		return 0, errors.New("otlp: exporter closed")
+	default:
+	}
+	select {
+	case e.queue <- rec:
+	default:
+		atomic.AddUint64(&e.dropped, 1)
+	}
+	return len(p), nil
+}
+
+// Dropped returns the number of records dropped because the queue was
+// full or could not be sent.
+func (e *Exporter) Dropped() uint64 {
+	return atomic.LoadUint64(&e.dropped)
+}
+
+// Flush sends the queued records and waits until it is done.
+func (e *Exporter) Flush() {
+	ack := make(chan struct{})
+	select {
+	case e.flush <- ack:
+		<-ack
+	case <-e.done:
+	}
+}
+
+// Close sends the queued records and stops the exporter.
+func (e *Exporter) Close() error {
+	e.closing.Do(func() { close(e.quit) })
+	<-e.done
+	return nil
+}
+
+func (e *Exporter) run() {
+	defer close(e.done)
+	ticker := time.NewTicker(e.cfg.FlushInterval)
+	defer ticker.Stop()
+	batch := make([]record, 0, e.cfg.BatchSize)
+	send := func() {
+		if len(batch) > 0 {
+			e.send(batch)
+			batch = batch[:0]
+		}
+	}
+	drain := func() {
+		for {
+			select {
+			case rec := <-e.queue:
+				if batch = append(batch, rec); len(batch) == e.cfg.BatchSize {
+					send()
+				}
+			default:
+				send()
+				return
+			}
+		}
+	}
+	for {
+		select {
+		case rec := <-e.queue:
+			if batch = append(batch, rec); len(batch) == e.cfg.BatchSize {
+				send()
+			}
+		case <-ticker.C:
+			send()
+		case ack := <-e.flush:
+			drain()
+			close(ack)
+		case <-e.quit:
+			drain()
+			return
+		}
+	}
+}
+
+func (e *Exporter) send(batch []record) {
+	body, err := json.Marshal(encode(batch))
+	if err != nil {
+		log.Printf("liblog: otlp: %v", err)
+		return
+	}
+	backoff := 100 * time.Millisecond
+	for attempt := 0; ; attempt++ {
+		retry, err := e.post(body)
+		if err == nil {
+			return
+		}
+		if !retry || attempt >= e.cfg.MaxRetries {
+			atomic.AddUint64(&e.dropped, uint64(len(batch)))
+			log.Printf("liblog: otlp: dropped %d records: %v", len(batch), err)
+			return
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// post sends one request, telling whether a failure is worth retrying.
+func (e *Exporter) post(body []byte) (retry bool, err error) {
+	req, err := http.NewRequest("POST", e.cfg.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range e.cfg.Headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := e.cfg.Client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == 429, resp.StatusCode >= 500:
+		return true, fmt.Errorf("server replied %s", resp.Status)
+	}
+	return false, fmt.Errorf("server replied %s", resp.Status)
+}
+
+type keyValue struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+}
+
+type resourceLogs struct {
+	Resource struct {
+		Attributes []keyValue `json:"attributes"`
+	} `json:"resource"`
+	ScopeLogs [1]scopeLogs `json:"scopeLogs"`
+}
+
+type scopeLogs struct {
+	Scope struct {
+		Name string `json:"name"`
+	} `json:"scope"`
+	LogRecords []logRecord `json:"logRecords"`
+}
+
+type logRecord struct {
+	// TimeUnixNano is the observed time when the timestamp is missing or
+	// cannot be read
+	TimeUnixNano         string      `json:"timeUnixNano,omitempty"`
+	ObservedTimeUnixNano string      `json:"observedTimeUnixNano,omitempty"`
+	SeverityNumber       int         `json:"severityNumber,omitempty"`
+	SeverityText         string      `json:"severityText,omitempty"`
+	Body                 interface{} `json:"body"`
+	Attributes           []keyValue  `json:"attributes,omitempty"`
+	TraceID              string      `json:"traceId,omitempty"`
+	SpanID               string      `json:"spanId,omitempty"`
+}
+
+// encode builds an ExportLogsServiceRequest with one resource per service.
+func encode(batch []record) interface{} {
+	var resources []*resourceLogs
+	byService := map[[2]string]*resourceLogs{}
+	for _, rec := range batch {
+		service, _ := rec.fields["service"].(string)
+		serviceID, _ := rec.fields["service_id"].(string)
+		res := byService[[2]string{service, serviceID}]
+		if res == nil {
+			res = new(resourceLogs)
+			res.Resource.Attributes = []keyValue{{"service.name", attribute(service)}}
+			if serviceID != "" {
+				res.Resource.Attributes = append(res.Resource.Attributes, keyValue{"service.instance.id", attribute(serviceID)})
+			}
+			res.ScopeLogs[0].Scope.Name = "liblog"
+			byService[[2]string{service, serviceID}] = res
+			resources = append(resources, res)
+		}
+		res.ScopeLogs[0].LogRecords = append(res.ScopeLogs[0].LogRecords, convert(rec))
+	}
+	return map[string]interface{}{"resourceLogs": resources}
+}
+
+func convert(rec record) logRecord {
+	fields := rec.fields
+	out := logRecord{ObservedTimeUnixNano: strconv.FormatInt(rec.observed.UnixNano(), 10)}
+	out.TimeUnixNano = out.ObservedTimeUnixNano
+	if t, ok := parseTimestamp(fields["timestamp"]); ok {
+		out.TimeUnixNano = strconv.FormatInt(t, 10)
+	}
+	if level, ok := fields["level"].(string); ok {
+		out.SeverityText = level
+		out.SeverityNumber = levelSeverity[level]
+	}
+	out.Body = attribute(fields["message"])
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		switch key {
+		case "timestamp", "level", "message", "service", "service_id":
+			continue
+		case "trace_id", "span_id":
+			if id, ok := fields[key].(string); ok && isHexID(id, key) {
+				if key == "trace_id" {
+					out.TraceID = id
+				} else {
+					out.SpanID = id
+				}
+				continue
+			}
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		out.Attributes = append(out.Attributes, keyValue{key, attribute(fields[key])})
+	}
+	return out
+}
+
+// parseTimestamp reads a timestamp in any liblog TimeFormat as nanoseconds
+// since the epoch: RFC 3339 strings, whatever their precision, or epoch
+// numbers, whose unit is told by their size.
+func parseTimestamp(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		return t.UnixNano(), err == nil
+	case json.Number:
+		n, err := strconv.ParseInt(string(v), 10, 64)
+		if err != nil || n <= 0 {
+			return 0, false
+		}
+		switch {
+		case n < 1e11:
+			return n * int64(time.Second), true
+		case n < 1e14:
+			return n * int64(time.Millisecond), true
+		case n < 1e17:
+			return n * int64(time.Microsecond), true
+		}
+		return n, true
+	}
+	return 0, false
+}
+
+func isHexID(id, key string) bool {
+	size := 16
+	if key == "span_id" {
+		size = 8
+	}
+	b, err := hex.DecodeString(id)
+	return err == nil && len(b) == size
+}
+
+// attribute converts a decoded JSON value to an OTLP AnyValue.
+func attribute(value interface{}) map[string]interface{} {
+	switch v := value.(type) {
+	case string:
+		return map[string]interface{}{"stringValue": v}
+	case bool:
+		return map[string]interface{}{"boolValue": v}
+	case json.Number:
+		if _, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			return map[string]interface{}{"intValue": string(v)}
+		}
+		f, _ := v.Float64()
+		return map[string]interface{}{"doubleValue": f}
+	case []interface{}:
+		values := make([]interface{}, len(v))
+		for i, item := range v {
+			values[i] = attribute(item)
+		}
+		return map[string]interface{}{"arrayValue": map[string]interface{}{"values": values}}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		values := make([]keyValue, len(keys))
+		for i, key := range keys {
+			values[i] = keyValue{key, attribute(v[key])}
+		}
+		return map[string]interface{}{"kvlistValue": map[string]interface{}{"values": values}}
+	}
+	return map[string]interface{}{}
+}
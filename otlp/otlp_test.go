@@ -0,0 +1,140 @@
+package otlp
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"runtime"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wimark/liblog"
+)
+
+type collector struct {
+	mu       sync.Mutex
+	failures int
+	requests []map[string]interface{}
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.failures > 0 {
+		c.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	body, _ := ioutil.ReadAll(r.Body)
+	var req map[string]interface{}
+	json.Unmarshal(body, &req)
+	c.requests = append(c.requests, req)
+}
+
+func TestExporter(t *testing.T) {
+	null, _ := os.Open(os.DevNull)
+	stdout := os.Stdout
+	os.Stdout = null
+	defer func() {
+		os.Stdout = stdout
+		null.Close()
+	}()
+
+	c := &collector{failures: 1}
+	server := httptest.NewServer(c)
+	defer server.Close()
+	exporter := New(Config{Endpoint: server.URL, FlushInterval: time.Hour})
+
+	logger := liblog.Init("otlp", liblog.WithSynchronous())
+	logger.AddWriter(exporter)
+	_, _, line, _ := runtime.Caller(0)
+	logger.With("user", 7, "trace_id", "0102030405060708090a0b0c0d0e0f10").WarningKV("quota exceeded")
+	logger.StopSync()
+	if err := exporter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(c.requests) != 1 {
+		t.Fatalf("got %d requests", len(c.requests))
+	}
+	resource := c.requests[0]["resourceLogs"].([]interface{})[0].(map[string]interface{})
+	wantResource := map[string]interface{}{"attributes": []interface{}{
+		map[string]interface{}{"key": "service.name", "value": map[string]interface{}{"stringValue": "otlp"}},
+	}}
+	if !reflect.DeepEqual(resource["resource"], wantResource) {
+		t.Errorf("resource %v", resource["resource"])
+	}
+	rec := resource["scopeLogs"].([]interface{})[0].(map[string]interface{})["logRecords"].([]interface{})[0].(map[string]interface{})
+	delete(rec, "timeUnixNano")
+	delete(rec, "observedTimeUnixNano")
+	want := map[string]interface{}{
+		"severityNumber": 13.0,
+		"severityText":   "WARNING",
+		"body":           map[string]interface{}{"stringValue": "quota exceeded"},
+		"traceId":        "0102030405060708090a0b0c0d0e0f10",
+		"attributes": []interface{}{
+			map[string]interface{}{"key": "src_file", "value": map[string]interface{}{"stringValue": "otlp_test.go"}},
+			map[string]interface{}{"key": "src_line", "value": map[string]interface{}{"intValue": strconv.Itoa(line + 1)}},
+			map[string]interface{}{"key": "user", "value": map[string]interface{}{"intValue": "7"}},
+		},
+	}
+	if !reflect.DeepEqual(rec, want) {
+		t.Errorf("record\n%v\nwant\n%v", rec, want)
+	}
+}
+
+func TestTimestamps(t *testing.T) {
+	null, _ := os.Open(os.DevNull)
+	stdout := os.Stdout
+	os.Stdout = null
+	defer func() {
+		os.Stdout = stdout
+		null.Close()
+	}()
+
+	// no worker is running, records stay in the queue
+	exporter := &Exporter{queue: make(chan record, 10), quit: make(chan struct{})}
+	logger := liblog.Init("otlp", liblog.WithSynchronous())
+	logger.AddWriter(exporter)
+	formats := []liblog.TimeFormat{liblog.TimeRFC3339Nano, liblog.TimeRFC3339Milli, liblog.TimeEpoch, liblog.TimeEpochMilli, liblog.TimeEpochNano}
+	before := time.Now().Add(-time.Second)
+	for _, format := range formats {
+		logger.SetTimeFormat(format)
+		logger.Info("timed")
+	}
+	logger.StopSync()
+	exporter.Write([]byte(`{"timestamp":"yesterday","message":"untimed"}`))
+	after := time.Now().Add(time.Second)
+
+	for i := 0; i <= len(formats); i++ {
+		out := convert(<-exporter.queue)
+		ns, _ := strconv.ParseInt(out.TimeUnixNano, 10, 64)
+		if ts := time.Unix(0, ns); ts.Before(before) || ts.After(after) {
+			t.Errorf("record %d: time %v out of range", i, ts)
+		}
+		if out.ObservedTimeUnixNano == "" {
+			t.Errorf("record %d: no observed time", i)
+		}
+	}
+}
+
+func TestExporterQueueFull(t *testing.T) {
+	// no worker is running, so the queue stays full
+	exporter := &Exporter{queue: make(chan record, 1), quit: make(chan struct{})}
+	for i := 0; i < 3; i++ {
+		if _, err := exporter.Write([]byte(`{"message":"x"}`)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := exporter.Dropped(); n != 2 {
+		t.Errorf("%d records dropped, want 2", n)
+	}
+	if _, err := exporter.Write([]byte("not json")); err == nil {
+		t.Errorf("invalid record accepted")
+	}
+}
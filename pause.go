@@ -0,0 +1,111 @@
+package liblog
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// PausePolicy tells what happens to the messages logged while the logger
+// is paused, see SetPausePolicy.
+type PausePolicy int
+
+const (
+	// PauseDrop, the default, drops them
+	PauseDrop PausePolicy = iota
+	// PauseBuffer keeps them, up to a limit, and logs them on Resume
+	PauseBuffer
+)
+
+// DefaultPauseBufferSize is the number of messages kept under PauseBuffer
+// unless SetPausePolicy says otherwise.
+const DefaultPauseBufferSize = 1024
+
+type pauseBuffer struct {
+	mu     sync.Mutex
+	policy PausePolicy
+	size   int
+	msgs   []LogMsg
+	lost   int
+}
+
+// Pause holds back every message logged until Resume, except those at or
+// above the level set with SetPauseBypass, FatalLevel by default so that
+// the record of a Fatal is never lost, dropping or buffering them as
+// set with SetPausePolicy. Messages are checked when they are logged,
+// before the level filter: a message bypassing the pause is still dropped
+// if it is below the logger's level.
+func (logger *Logger) Pause() {
+	atomic.StoreInt32(&logger.paused, 1)
+}
+
+// Resume ends a pause, logging the messages buffered under PauseBuffer in
+// the order they were logged, before any message logged during Resume.
+// The number of messages that did not fit in the buffer is reported
+// through the standard logger.
+func (logger *Logger) Resume() {
+	logger.closeMu.RLock()
+	logger.pause.mu.Lock()
+	// messages logged meanwhile wait in pauseTake until the replay is done
+	if !logger.closed {
+		for _, msg := range logger.pause.msgs {
+			logger.enqueue(msg)
+		}
+	}
+	lost := logger.pause.lost
+	logger.pause.msgs, logger.pause.lost = nil, 0
+	atomic.StoreInt32(&logger.paused, 0)
+	logger.pause.mu.Unlock()
+	logger.closeMu.RUnlock()
+	if lost > 0 {
+		internalf("%d messages logged while paused did not fit in the buffer", lost)
+	}
+}
+
+// SetPauseBypass lets messages at level or above through while the logger
+// is paused, e.g. SetPauseBypass(ErrorLevel) to never silence errors. A
+// level above FatalLevel holds back Fatal records too, which are then lost
+// as the process exits.
+func (logger *Logger) SetPauseBypass(level LogLevel) {
+	atomic.StoreInt32(&logger.pauseBypass, int32(level))
+}
+
+// SetPausePolicy chooses between dropping and buffering the messages
+// logged while paused. Under PauseBuffer up to size messages are kept,
+// DefaultPauseBufferSize if size <= 0, and later ones are dropped.
+// Messages still buffered when the logger stops are lost.
+func (logger *Logger) SetPausePolicy(policy PausePolicy, size int) {
+	if size <= 0 {
+		size = DefaultPauseBufferSize
+	}
+	logger.pause.mu.Lock()
+	logger.pause.policy, logger.pause.size = policy, size
+	logger.pause.mu.Unlock()
+}
+
+// pauseTake reports whether msg is held back by a pause, and if so whether
+// it was buffered rather than dropped.
+func (logger *Logger) pauseTake(msg LogMsg) (taken, kept bool) {
+	if !logger.pausedFor(msg.Level) {
+		return false, false
+	}
+	logger.pause.mu.Lock()
+	defer logger.pause.mu.Unlock()
+	// Resume may have run since the check
+	if atomic.LoadInt32(&logger.paused) == 0 {
+		return false, false
+	}
+	if logger.pause.policy != PauseBuffer {
+		return true, false
+	}
+	if len(logger.pause.msgs) >= logger.pause.size {
+		logger.pause.lost++
+		return true, false
+	}
+	logger.pause.msgs = append(logger.pause.msgs, msg)
+	return true, true
+}
+
+func (logger *Logger) pausedFor(level LogLevel) bool {
+	return atomic.LoadInt32(&logger.paused) == 1 &&
+		int32(level) < atomic.LoadInt32(&logger.pauseBypass)
+}
@@ -0,0 +1,76 @@
+package liblog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPause(t *testing.T) {
+	defer quiet()()
+	logger := Init("pause")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+
+	logger.Info("before")
+	logger.Pause()
+	logger.Info("paused")
+	logger.Error("paused error")
+	logger.SetPauseBypass(ErrorLevel)
+	logger.Warning("paused warning")
+	logger.Error("bypass")
+	logger.Resume()
+	logger.Info("after")
+	logger.StopSync()
+
+	var messages []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		messages = append(messages, line[strings.Index(line, `"message"`):strings.Index(line, `,"service"`)])
+	}
+	got := strings.Join(messages, " ")
+	if want := `"message":"before" "message":"bypass" "message":"after"`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestPauseBuffer(t *testing.T) {
+	defer quiet()()
+	logger := Init("pause")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+
+	logger.SetPausePolicy(PauseBuffer, 2)
+	logger.Pause()
+	logger.Info("first")
+	if !logger.LogWithTimeout(time.Second, InfoLevel, "second") {
+		t.Error("buffered message reported as not queued")
+	}
+	logger.Info("lost")
+	logger.Resume()
+	logger.Info("after")
+	logger.StopSync()
+
+	out := buf.String()
+	first, second, after := strings.Index(out, `"first"`), strings.Index(out, `"second"`), strings.Index(out, `"after"`)
+	if first < 0 || second < first || after < second || strings.Contains(out, `"lost"`) {
+		t.Errorf("unexpected output: %s", out)
+	}
+}
+
+func TestPauseFatal(t *testing.T) {
+	defer quiet()()
+	logger := Init("pause")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+	logger.SetExitFunc(func(int) {})
+
+	logger.SetPausePolicy(PauseBuffer, 0)
+	logger.Pause()
+	logger.Error("held")
+	logger.Fatal("fatal")
+
+	if out := buf.String(); !strings.Contains(out, `"message":"fatal"`) || strings.Contains(out, `"held"`) {
+		t.Errorf("unexpected output: %s", out)
+	}
+}
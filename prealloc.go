@@ -0,0 +1,42 @@
+package liblog
+
+import (
+	"bytes"
+	"sync/atomic"
+)
+
+// WithBufferPreAlloc makes the logger grow the buffer of every record to
+// at least n bytes before formatting it, so that records up to that size
+// are formatted without reallocating. By default, or with n <= 0, the
+// size follows the average size of the records written so far.
+//
+// Buffers are pooled and keep their capacity, so this mostly matters for
+// the fresh buffers allocated when the pool was emptied by the garbage
+// collector.
+func WithBufferPreAlloc(n int) Option {
+	return func(logger *Logger) {
+		atomic.StoreInt64(&logger.preAlloc, int64(n))
+	}
+}
+
+func (logger *Logger) preGrow(buf *bytes.Buffer) {
+	n := int(atomic.LoadInt64(&logger.preAlloc))
+	if n <= 0 {
+		n = int(atomic.LoadInt64(&logger.recordSize))
+	}
+	if n > buf.Cap() {
+		buf.Grow(n)
+	}
+}
+
+// observeRecord moves the average record size an eighth of the way
+// towards size. Records may be formatted concurrently, e.g. by inline
+// writes of SetSyncFromLevel, hence the compare-and-swap.
+func (logger *Logger) observeRecord(size int) {
+	for {
+		avg := atomic.LoadInt64(&logger.recordSize)
+		if atomic.CompareAndSwapInt64(&logger.recordSize, avg, avg+(int64(size)-avg)/8) {
+			return
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package liblog
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestBufferPreAlloc(t *testing.T) {
+	defer quiet()()
+	logger := Init("prealloc", WithSynchronous(), WithOutput(&bytes.Buffer{}), WithBufferPreAlloc(1024))
+	defer logger.StopSync()
+	var buf bytes.Buffer
+	logger.preGrow(&buf)
+	if buf.Cap() < 1024 {
+		t.Errorf("cap = %d, want at least 1024", buf.Cap())
+	}
+
+	adaptive := Init("prealloc", WithSynchronous(), WithOutput(&bytes.Buffer{}))
+	defer adaptive.StopSync()
+	for i := 0; i < 100; i++ {
+		adaptive.Info(strings.Repeat("x", 2000))
+	}
+	buf = bytes.Buffer{}
+	adaptive.preGrow(&buf)
+	if buf.Cap() < 2000 {
+		t.Errorf("adaptive cap = %d, want at least 2000", buf.Cap())
+	}
+}
+
+// BenchmarkBufferPreAlloc formats 600-byte records into fresh buffers, as
+// on a pool miss, to compare a small pre-grow size with a matching one.
+func BenchmarkBufferPreAlloc(b *testing.B) {
+	defer quiet()()
+	msg := LogMsg{Level: InfoLevel, Module: "bench", Message: strings.Repeat("m", 500)}
+	for _, size := range []int{128, 1024} {
+		logger := Init("bench", WithSynchronous(), WithBufferPreAlloc(size))
+		defer logger.StopSync()
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				buf := new(bytes.Buffer)
+				logger.preGrow(buf)
+				JSONFormatter{}.Format(buf, &msg, FormatOptions{})
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package liblog
+
+import "strings"
+
+// Redacted replaces the values of the fields named with RedactKeys.
+const Redacted = "[REDACTED]"
+
+type redactor struct {
+	exact    map[string]bool
+	prefixes []string
+	suffixes []string
+}
+
+// RedactKeys makes fields whose key matches one of keys, ignoring case,
+// be written with the value Redacted, whatever their value, in groups too.
+// A key ending with '*' matches the keys starting with the rest of it, a
+// key starting with '*' the keys ending with the rest of it: "*_secret"
+// redacts "db_secret" and "API_SECRET". Only field keys are matched, not
+// the message. Each call replaces the previous keys; no keys turns
+// redaction off.
+func (logger *Logger) RedactKeys(keys ...string) {
+	var r *redactor
+	if len(keys) > 0 {
+		r = &redactor{exact: make(map[string]bool)}
+		for _, key := range keys {
+			key = strings.ToLower(key)
+			switch {
+			case strings.HasSuffix(key, "*"):
+				r.prefixes = append(r.prefixes, strings.TrimSuffix(key, "*"))
+			case strings.HasPrefix(key, "*"):
+				r.suffixes = append(r.suffixes, strings.TrimPrefix(key, "*"))
+			default:
+				r.exact[key] = true
+			}
+		}
+	}
+	logger.mu.Lock()
+	logger.redactor = r
+	logger.mu.Unlock()
+}
+
+func (logger *Logger) redact(msg *LogMsg) {
+	logger.mu.RLock()
+	r := logger.redactor
+	logger.mu.RUnlock()
+	if r != nil && r.any(msg.Fields) {
+		msg.Fields = r.apply(msg.Fields)
+	}
+}
+
+func (r *redactor) matches(key string) bool {
+	key = strings.ToLower(key)
+	if r.exact[key] {
+		return true
+	}
+	for _, prefix := range r.prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	for _, suffix := range r.suffixes {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *redactor) any(fields []Field) bool {
+	for _, field := range fields {
+		if r.matches(field.Key) {
+			return true
+		}
+		if group, ok := field.Value.(Fields); ok && r.any(group) {
+			return true
+		}
+	}
+	return false
+}
+
+// apply returns a copy of fields with the matching values redacted, since
+// fields may be shared with the logger.
+func (r *redactor) apply(fields []Field) []Field {
+	redacted := make([]Field, len(fields))
+	for i, field := range fields {
+		if r.matches(field.Key) {
+			field.Value = Redacted
+		} else if group, ok := field.Value.(Fields); ok && r.any(group) {
+			field.Value = Fields(r.apply(group))
+		}
+		redacted[i] = field
+	}
+	return redacted
+}
@@ -0,0 +1,30 @@
+package liblog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRedactKeys(t *testing.T) {
+	defer quiet()()
+	logger := Init("redact")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+	logger.RedactKeys("Password", "*_secret", "token_*")
+
+	logger.With("user", "bob", "password", "hunter2").
+		Group("db").InfoKV("connected", "DB_SECRET", "s3", "token_id", 1, "host", "db1")
+	logger.RedactKeys()
+	logger.InfoKV("off", "password", "visible")
+	logger.StopSync()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := `"user":"bob","password":"[REDACTED]","db":{"DB_SECRET":"[REDACTED]","token_id":"[REDACTED]","host":"db1"}}`
+	if !strings.HasSuffix(lines[0], want) {
+		t.Errorf("got %s, want suffix %s", lines[0], want)
+	}
+	if !strings.HasSuffix(lines[1], `"password":"visible"}`) {
+		t.Errorf("redaction not turned off: %s", lines[1])
+	}
+}
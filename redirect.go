@@ -0,0 +1,83 @@
+package liblog
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// RedirectStandardStreams replaces os.Stdout and os.Stderr with pipes and
+// logs every line written to them at Info and Error level respectively.
+// A logger writing to stdout keeps writing to the original one; one set
+// up with WithOutput or LOGOUTPUT keeps its output. The returned
+// restore function puts both streams back and waits until everything
+// written so far has been passed to the logger; call it before stopping
+// the logger.
+//
+// Records the logger itself sends to standard error, see SetSplitStreams,
+// go to the stderr it captured at Init rather than to the pipe, so they
+// are not logged again.
+//
+// Only writes going through the os.Stdout/os.Stderr variables are
+// captured: the runtime prints crash traces straight to file descriptor 2.
+func RedirectStandardStreams(logger *Logger) (restore func(), err error) {
+	outR, outW, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	errR, errW, err := os.Pipe()
+	if err != nil {
+		outR.Close()
+		outW.Close()
+		return nil, err
+	}
+
+	stdout, stderr := os.Stdout, os.Stderr
+	// only a logger following os.Stdout needs to be pinned to it
+	logger.mu.Lock()
+	pinned := logger.stdout == nil
+	if pinned {
+		logger.stdout = stdout
+	}
+	logger.mu.Unlock()
+	os.Stdout, os.Stderr = outW, errW
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go logger.forwardLines(outR, InfoLevel, &wg)
+	go logger.forwardLines(errR, ErrorLevel, &wg)
+
+	var once sync.Once
+	restore = func() {
+		once.Do(func() {
+			os.Stdout, os.Stderr = stdout, stderr
+			outW.Close()
+			errW.Close()
+			wg.Wait()
+			outR.Close()
+			errR.Close()
+			if pinned {
+				logger.setStdout(nil)
+			}
+		})
+	}
+	return restore, nil
+}
+
+func (logger *Logger) forwardLines(r io.Reader, level LogLevel, wg *sync.WaitGroup) {
+	defer wg.Done()
+	reader := bufio.NewReader(r)
+	for {
+		// a trailing partial line is returned together with io.EOF
+		line, err := reader.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		if line != "" {
+			logger.send(logger.newMsg(level, line))
+		}
+		if err != nil {
+			return
+		}
+	}
+}
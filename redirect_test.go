@@ -0,0 +1,85 @@
+package liblog
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRedirectStandardStreams(t *testing.T) {
+	defer quiet()()
+	logger := Init("redirect")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+
+	restore, err := RedirectStandardStreams(logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println("to stdout")
+	fmt.Fprint(os.Stderr, "partial ")
+	fmt.Fprint(os.Stderr, "line")
+	restore()
+	logger.StopSync()
+
+	out := buf.String()
+	if !strings.Contains(out, `"level":"INFO","message":"to stdout"`) {
+		t.Errorf("stdout line not logged at info: %s", out)
+	}
+	if !strings.Contains(out, `"level":"ERROR","message":"partial line"`) {
+		t.Errorf("stderr partial line not logged at error: %s", out)
+	}
+}
+
+func TestRedirectKeepsOutput(t *testing.T) {
+	defer quiet()()
+	var out bytes.Buffer
+	logger := Init("redirect", WithOutput(&out))
+
+	restore, err := RedirectStandardStreams(logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println("to stdout")
+	restore()
+	logger.StopSync()
+
+	if !strings.Contains(out.String(), `"message":"to stdout"`) {
+		t.Errorf("configured output not kept: %q", out.String())
+	}
+}
+
+func TestRedirectSplitStreams(t *testing.T) {
+	defer quiet()()
+	stderr, err := ioutil.TempFile("", "stderr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(stderr.Name())
+	defer stderr.Close()
+	os.Stderr = stderr
+	var out bytes.Buffer
+	logger := Init("redirect", WithSynchronous(), WithOutput(&out))
+	logger.SetSplitStreams(true)
+
+	restore, err := RedirectStandardStreams(logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Error("boom")
+	time.Sleep(100 * time.Millisecond)
+	restore()
+	logger.StopSync()
+
+	errOut, _ := ioutil.ReadFile(stderr.Name())
+	if n := strings.Count(string(errOut), "\n"); n != 1 || !strings.Contains(string(errOut), `"message":"boom"`) {
+		t.Errorf("want one record on stderr, got %q", errOut)
+	}
+	if out.Len() != 0 {
+		t.Errorf("stderr record logged again: %q", out.String())
+	}
+}
@@ -0,0 +1,119 @@
+package liblog
+
+import (
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+)
+
+// Reopener is implemented by file-backed writers that can close and reopen
+// their file, such as RotatingFileWriter and the LOGOUTPUT file.
+type Reopener interface {
+	Reopen() error
+}
+
+// Reopen flushes the stdout buffer and reopens every output implementing
+// Reopener, so that a file moved away by an external tool such as
+// logrotate is created again, while the logger keeps running. Every
+// output is tried; the first error is returned.
+func (logger *Logger) Reopen() error {
+	err := logger.Flush()
+	logger.mu.RLock()
+	outputs := append([]io.Writer{logger.stdout}, logger.writers...)
+	for _, route := range logger.routes {
+		outputs = append(outputs, route.writers...)
+	}
+	logger.mu.RUnlock()
+	for _, output := range outputs {
+		if r, ok := output.(Reopener); ok {
+			if reopenErr := r.Reopen(); err == nil {
+				err = reopenErr
+			}
+		}
+	}
+	return err
+}
+
+// ReopenOnSignal calls Reopen every time the process receives one of
+// signals, reporting failures through the standard logger. Without
+// signals it uses SIGHUP on Unix; elsewhere it reports the missing
+// signals and installs no handler. The returned function removes the
+// handler.
+func (logger *Logger) ReopenOnSignal(signals ...os.Signal) (stop func()) {
+	if len(signals) == 0 {
+		signals = defaultReopenSignals
+	}
+	if len(signals) == 0 {
+		internalf("ReopenOnSignal: no signals given and no SIGHUP on this platform")
+		return func() {}
+	}
+	ch := make(chan os.Signal, 1)
+	quit := make(chan struct{})
+	signal.Notify(ch, signals...)
+	go func() {
+		for {
+			select {
+			case <-ch:
+				if err := logger.Reopen(); err != nil {
+					internalf("reopening outputs: %v", err)
+				}
+			case <-quit:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(quit)
+		})
+	}
+}
+
+// reopenFile is the LOGOUTPUT file.
+type reopenFile struct {
+	path string
+	mu   sync.Mutex
+	file *os.File
+}
+
+func openAppend(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+}
+
+func (f *reopenFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	return f.file.Write(p)
+}
+
+func (f *reopenFile) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return os.ErrClosed
+	}
+	file, err := openAppend(f.path)
+	if err != nil {
+		return err
+	}
+	f.file.Close()
+	f.file = file
+	return nil
+}
+
+func (f *reopenFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return os.ErrClosed
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
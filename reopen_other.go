@@ -0,0 +1,10 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package liblog
+
+import "os"
+
+// defaultReopenSignals is empty where there is no SIGHUP: ReopenOnSignal
+// needs the signals given explicitly.
+var defaultReopenSignals []os.Signal
@@ -0,0 +1,52 @@
+package liblog
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReopen(t *testing.T) {
+	defer quiet()()
+	dir, err := ioutil.TempDir("", "liblog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	out, rotating := filepath.Join(dir, "out.log"), filepath.Join(dir, "rotating.log")
+	os.Setenv("LOGOUTPUT", out)
+	defer os.Unsetenv("LOGOUTPUT")
+
+	logger := Init("reopen", WithSynchronous())
+	w, err := NewRotatingFileWriter(rotating, 1<<20, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	logger.AddWriter(w)
+
+	logger.Info("before")
+	for _, path := range []string{out, rotating} {
+		if err := os.Rename(path, path+".1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := logger.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("after")
+	logger.StopSync()
+
+	for _, path := range []string{out, rotating} {
+		moved, _ := ioutil.ReadFile(path + ".1")
+		created, _ := ioutil.ReadFile(path)
+		if !strings.Contains(string(moved), `"before"`) || strings.Contains(string(moved), `"after"`) {
+			t.Errorf("%s moved: %s", path, moved)
+		}
+		if !strings.Contains(string(created), `"after"`) || strings.Contains(string(created), `"before"`) {
+			t.Errorf("%s recreated: %s", path, created)
+		}
+	}
+}
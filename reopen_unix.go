@@ -0,0 +1,11 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package liblog
+
+import (
+	"os"
+	"syscall"
+)
+
+var defaultReopenSignals = []os.Signal{syscall.SIGHUP}
@@ -0,0 +1,25 @@
+package liblog
+
+import (
+	"net/http"
+	"time"
+)
+
+// RequestFields returns the fields of an access log entry: method, path,
+// remote_addr, status, duration_ms and user_agent.
+func RequestFields(r *http.Request, status int, duration time.Duration) []Field {
+	return []Field{
+		{"method", r.Method},
+		{"path", r.URL.Path},
+		{"remote_addr", r.RemoteAddr},
+		{"status", status},
+		{"duration_ms", float64(duration) / float64(time.Millisecond)},
+		{"user_agent", r.UserAgent()},
+	}
+}
+
+// LogRequest writes an access log entry for r at Info level, with the
+// fields of RequestFields.
+func (logger *Logger) LogRequest(r *http.Request, status int, duration time.Duration) {
+	logger.log(InfoLevel, RequestFields(r, status, duration), "%s %s %d", r.Method, r.URL.Path, status)
+}
@@ -0,0 +1,27 @@
+package liblog
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogRequest(t *testing.T) {
+	defer quiet()()
+	logger := Init("access")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+
+	r := httptest.NewRequest("GET", "/users/7?full=1", nil)
+	r.Header.Set("User-Agent", "curl/7.68")
+	logger.LogRequest(r, 404, 1500*time.Microsecond)
+	logger.StopSync()
+
+	out := buf.String()
+	if !strings.Contains(out, `"message":"GET /users/7 404"`) ||
+		!strings.HasSuffix(out, `"method":"GET","path":"/users/7","remote_addr":"192.0.2.1:1234","status":404,"duration_ms":1.5,"user_agent":"curl/7.68"}`+"\n") {
+		t.Errorf("unexpected output: %s", out)
+	}
+}
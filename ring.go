@@ -0,0 +1,81 @@
+package liblog
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+// ring keeps the last records formatted by a logger.
+type ring struct {
+	// level is accessed atomically; unsetRingLevel follows the logger
+	level int32
+
+	mu      sync.Mutex
+	records []string
+	next    int
+	full    bool
+}
+
+const unsetRingLevel = math.MinInt32
+
+// EnableRingBuffer keeps the last n records written by the logger in
+// memory for DumpRecent, e.g. to report what happened before a crash. n of
+// 0 turns it off.
+func (logger *Logger) EnableRingBuffer(n int) {
+	var r *ring
+	if n > 0 {
+		r = &ring{level: unsetRingLevel, records: make([]string, n)}
+	}
+	logger.mu.Lock()
+	logger.ring = r
+	logger.mu.Unlock()
+}
+
+// SetRingBufferLevel makes the ring buffer keep records at level or above
+// instead of the written ones, including records below the logger's
+// level, which are then formatted just for the ring buffer.
+func (logger *Logger) SetRingBufferLevel(level LogLevel) {
+	if r := logger.ringBuffer(); r != nil {
+		atomic.StoreInt32(&r.level, int32(level))
+	}
+}
+
+// DumpRecent returns the records kept by the ring buffer, oldest first.
+func (logger *Logger) DumpRecent() []string {
+	r := logger.ringBuffer()
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.full {
+		return append([]string(nil), r.records[:r.next]...)
+	}
+	return append(append([]string(nil), r.records[r.next:]...), r.records[:r.next]...)
+}
+
+func (logger *Logger) ringBuffer() *ring {
+	logger.mu.RLock()
+	defer logger.mu.RUnlock()
+	return logger.ring
+}
+
+// keeps tells whether the ring takes a record at level when the logger
+// writes records at loggerLevel or above.
+func (r *ring) keeps(level, loggerLevel LogLevel) bool {
+	if own := atomic.LoadInt32(&r.level); own != unsetRingLevel {
+		return int32(level) >= own
+	}
+	return level >= loggerLevel
+}
+
+func (r *ring) add(record []byte) {
+	r.mu.Lock()
+	r.records[r.next] = string(record)
+	r.next++
+	if r.next == len(r.records) {
+		r.next, r.full = 0, true
+	}
+	r.mu.Unlock()
+}
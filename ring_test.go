@@ -0,0 +1,37 @@
+package liblog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRingBuffer(t *testing.T) {
+	defer quiet()()
+	logger := Init("ring", WithSynchronous())
+	defer logger.StopSync()
+	logger.SetLevel(InfoLevel)
+	if logger.DumpRecent() != nil {
+		t.Errorf("records kept without a ring buffer")
+	}
+
+	logger.EnableRingBuffer(3)
+	logger.Debug("skipped")
+	logger.Info("one")
+	logger.Info("two")
+	if got := logger.DumpRecent(); len(got) != 2 || !strings.Contains(got[0], `"message":"one"`) {
+		t.Errorf("partial ring: %q", got)
+	}
+
+	logger.SetRingBufferLevel(DebugLevel)
+	logger.Debug("kept in the ring only")
+	logger.Info("three")
+	got := logger.DumpRecent()
+	var messages []string
+	for _, record := range got {
+		messages = append(messages, record[strings.Index(record, `"message"`):strings.Index(record, `,"service"`)])
+	}
+	want := `"message":"two" "message":"kept in the ring only" "message":"three"`
+	if strings.Join(messages, " ") != want {
+		t.Errorf("got %s, want %s", strings.Join(messages, " "), want)
+	}
+}
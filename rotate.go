@@ -0,0 +1,213 @@
+package liblog
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RotatingFileWriter appends to a file and, when a write would make it
+// larger than the size limit, renames it with a timestamp suffix and starts
+// a new one. Rotated files are gzip-compressed in the background when
+// compression is on, and only the newest maxBackups of them are kept
+// (all of them if maxBackups is 0).
+type RotatingFileWriter struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	compress   bool
+
+	mu sync.Mutex
+	// file is nil after a failed rotation until it can be opened again
+	file   *os.File
+	size   int64
+	closed bool
+	// rotated files waiting for the cleanup goroutine, which runs until
+	// the queue is empty; pending tracks it for Close
+	queue   []string
+	pending sync.WaitGroup
+}
+
+func NewRotatingFileWriter(path string, maxSize int64, maxBackups int, compress bool) (*RotatingFileWriter, error) {
+	w := &RotatingFileWriter{path: path, maxSize: maxSize, maxBackups: maxBackups, compress: compress}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *RotatingFileWriter) open() error {
+	file, err := os.OpenFile(w.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	w.file, w.size = file, info.Size()
+	return nil
+}
+
+func (w *RotatingFileWriter) Write(p []byte) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+	if w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err = w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Reopen closes the file and opens it again, creating it if it was moved
+// away, for external rotation tools.
+func (w *RotatingFileWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return os.ErrClosed
+	}
+	old := w.file
+	if err := w.open(); err != nil {
+		return err
+	}
+	if old == nil {
+		return nil
+	}
+	return old.Close()
+}
+
+func (w *RotatingFileWriter) rotate() error {
+	// closed first: open files cannot be renamed everywhere
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	w.file = nil
+	rotated := w.path + "." + time.Now().UTC().Format("20060102T150405.000000000")
+	if err := os.Rename(w.path, rotated); err != nil {
+		// keep appending to the current file; the next write retries
+		w.open()
+		return err
+	}
+	if err := w.open(); err != nil {
+		return err
+	}
+	w.queue = append(w.queue, rotated)
+	if len(w.queue) == 1 {
+		w.pending.Add(1)
+		go w.cleanup()
+	}
+	return nil
+}
+
+// cleanup compresses the queued rotated files in order and removes old
+// backups, so that files rotated while a previous one is being compressed
+// wait for their turn.
+func (w *RotatingFileWriter) cleanup() {
+	defer w.pending.Done()
+	w.mu.Lock()
+	rotated := w.queue[0]
+	w.mu.Unlock()
+	for {
+		// a file may already be gone, removed as an old backup while
+		// waiting in the queue
+		if w.compress {
+			if err := compressFile(rotated); err != nil && !os.IsNotExist(err) {
+				internalf("compressing %s: %v", rotated, err)
+			}
+		}
+		if w.maxBackups > 0 {
+			w.removeOldBackups()
+		}
+		w.mu.Lock()
+		w.queue = w.queue[1:]
+		if len(w.queue) == 0 {
+			w.mu.Unlock()
+			return
+		}
+		rotated = w.queue[0]
+		w.mu.Unlock()
+	}
+}
+
+func compressFile(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	tmp := name + ".gz.tmp"
+	dst, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	_, err = io.Copy(zw, src)
+	if err == nil {
+		err = zw.Close()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, name+".gz")
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Remove(name)
+}
+
+func (w *RotatingFileWriter) removeOldBackups() {
+	names, err := filepath.Glob(w.path + ".*")
+	if err != nil {
+		return
+	}
+	backups := names[:0]
+	for _, name := range names {
+		if !strings.HasSuffix(name, ".tmp") {
+			backups = append(backups, name)
+		}
+	}
+	// timestamp suffixes sort chronologically
+	sort.Strings(backups)
+	for len(backups) > w.maxBackups {
+		if err := os.Remove(backups[0]); err != nil {
+			internalf("removing old log file: %v", err)
+		}
+		backups = backups[1:]
+	}
+}
+
+// Close closes the file and waits for the compression of rotated files to
+// finish.
+func (w *RotatingFileWriter) Close() error {
+	w.mu.Lock()
+	w.closed = true
+	var err error
+	if w.file != nil {
+		err = w.file.Close()
+		w.file = nil
+	}
+	w.mu.Unlock()
+	w.pending.Wait()
+	return err
+}
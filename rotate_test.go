@@ -0,0 +1,85 @@
+package liblog
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestRotatingFileWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "liblog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.log")
+	w, err := NewRotatingFileWriter(path, 20, 2, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		fmt.Fprintf(w, "record %d of 5\n", i)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	names, _ := filepath.Glob(path + ".*")
+	sort.Strings(names)
+	if len(names) != 2 {
+		t.Fatalf("kept %d backups: %v", len(names), names)
+	}
+	for i, name := range names {
+		if !strings.HasSuffix(name, ".gz") {
+			t.Fatalf("backup not compressed: %s", name)
+		}
+		f, err := os.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadAll(zr)
+		f.Close()
+		if want := fmt.Sprintf("record %d of 5\n", i+2); string(data) != want || err != nil {
+			t.Errorf("%s holds %q, %v, want %q", name, data, err, want)
+		}
+	}
+	if data, _ := ioutil.ReadFile(path); string(data) != "record 4 of 5\n" {
+		t.Errorf("current file holds %q", data)
+	}
+}
+
+func TestRotatingFileWriterFailedRename(t *testing.T) {
+	dir, err := ioutil.TempDir("", "liblog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.log")
+	w, err := NewRotatingFileWriter(path, 20, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	fmt.Fprint(w, "record 1 of 3\n")
+	// the rename of the rotation fails on the missing file
+	os.Remove(path)
+	if _, err := fmt.Fprint(w, "record 2 of 3\n"); err == nil {
+		t.Fatal("failed rotation not reported")
+	}
+	if _, err := fmt.Fprint(w, "record 3 of 3\n"); err != nil {
+		t.Fatalf("writer unusable after a failed rotation: %v", err)
+	}
+	if data, _ := ioutil.ReadFile(path); string(data) != "record 3 of 3\n" {
+		t.Errorf("current file holds %q", data)
+	}
+}
@@ -0,0 +1,45 @@
+package liblog
+
+import (
+	"io"
+	"reflect"
+)
+
+type route struct {
+	match   func(msg *LogMsg) bool
+	writers []io.Writer
+}
+
+// AddRoute sends the messages for which match returns true to writers
+// only, instead of stdout and the writers added with AddWriter. Routes are
+// tried in the order they were added and the first match wins. match is
+// called on the worker goroutine and must not keep msg.
+func (logger *Logger) AddRoute(match func(msg *LogMsg) bool, writers ...io.Writer) {
+	logger.mu.Lock()
+	logger.routes = append(logger.routes, &route{match, writers})
+	logger.mu.Unlock()
+}
+
+func (logger *Logger) route(msg *LogMsg) *route {
+	logger.mu.RLock()
+	defer logger.mu.RUnlock()
+	for _, r := range logger.routes {
+		if r.match(msg) {
+			return r
+		}
+	}
+	return nil
+}
+
+// HasField returns a route predicate matching messages with a field equal
+// to the given key and value, e.g. HasField("audit", true).
+func HasField(key string, value interface{}) func(msg *LogMsg) bool {
+	return func(msg *LogMsg) bool {
+		for _, field := range msg.Fields {
+			if field.Key == key && reflect.DeepEqual(field.Value, value) {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,33 @@
+package liblog
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestAddRoute(t *testing.T) {
+	defer quiet()()
+	logger := Init("route")
+	var main, audit, errors bytes.Buffer
+	logger.AddWriter(&main)
+	logger.AddRoute(HasField("audit", true), &audit)
+	logger.AddRoute(func(msg *LogMsg) bool { return msg.Level >= ErrorLevel }, &errors)
+
+	logger.InfoCtx(ContextWith(context.Background(), Any("audit", true)), "user deleted")
+	logger.ErrorCtx(ContextWith(context.Background(), Any("audit", true)), "audit first")
+	logger.Error("failure")
+	logger.Info("regular")
+	logger.StopSync()
+
+	if out := audit.String(); !strings.Contains(out, "user deleted") || !strings.Contains(out, "audit first") || strings.Contains(out, "regular") {
+		t.Errorf("audit got: %s", out)
+	}
+	if out := errors.String(); !strings.Contains(out, "failure") || strings.Contains(out, "audit first") {
+		t.Errorf("errors got: %s", out)
+	}
+	if out := main.String(); strings.Count(out, "\n") != 1 || !strings.Contains(out, "regular") {
+		t.Errorf("main got: %s", out)
+	}
+}
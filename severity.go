@@ -0,0 +1,58 @@
+package liblog
+
+// DefaultSeverity is the mapping in effect unless SetSeverityMapper says
+// otherwise: the level name and the level itself, DEBUG 0 to FATAL 4.
+// Formats only write the name in that case, as "level".
+func DefaultSeverity(level LogLevel) (label string, number int) {
+	return level.String(), int(level)
+}
+
+// GCPSeverity maps levels to Google Cloud Logging severities.
+func GCPSeverity(level LogLevel) (label string, number int) {
+	switch {
+	case level < InfoLevel:
+		return "DEBUG", 100
+	case level < WarningLevel:
+		return "INFO", 200
+	case level < ErrorLevel:
+		return "WARNING", 400
+	case level < FatalLevel:
+		return "ERROR", 500
+	}
+	return "CRITICAL", 600
+}
+
+// OTelSeverity maps levels to OpenTelemetry severity texts and numbers.
+func OTelSeverity(level LogLevel) (label string, number int) {
+	switch {
+	case level < InfoLevel:
+		return "DEBUG", 5
+	case level < WarningLevel:
+		return "INFO", 9
+	case level < ErrorLevel:
+		return "WARN", 13
+	case level < FatalLevel:
+		return "ERROR", 17
+	}
+	return "FATAL", 21
+}
+
+// SetSeverityMapper makes the JSON, logfmt and msgpack formats write the
+// label returned by mapper as "level", followed by its number as
+// "severity", to match the severity scheme of a backend, e.g. GCPSeverity
+// or OTelSeverity. nil goes back to DefaultSeverity, without "severity".
+func (logger *Logger) SetSeverityMapper(mapper func(level LogLevel) (label string, number int)) {
+	logger.mu.Lock()
+	logger.format.Severity = mapper
+	logger.mu.Unlock()
+}
+
+// SeverityOf returns the label and number of level under opts.Severity,
+// with ok false when no mapper is set and only the level name is written.
+func (opts FormatOptions) SeverityOf(level LogLevel) (label string, number int, ok bool) {
+	if opts.Severity == nil {
+		return level.String(), int(level), false
+	}
+	label, number = opts.Severity(level)
+	return label, number, true
+}
@@ -0,0 +1,30 @@
+package liblog
+
+import (
+	"context"
+	"io"
+)
+
+// Shutdown stops accepting messages, waits until the queued ones are
+// written and closes the writers implementing io.Closer, returning the
+// first error from closing them. If ctx is done first, Shutdown reports
+// how many messages were still queued and returns ctx.Err(); those
+// messages are written in the background but the writers stay open.
+func (logger *Logger) Shutdown(ctx context.Context) error {
+	go logger.closeOutput()
+	select {
+	case <-logger.done:
+	case <-ctx.Done():
+		internalf("shutdown interrupted with %d messages queued", logger.QueueDepth())
+		return ctx.Err()
+	}
+	var firstErr error
+	for _, w := range logger.currentWriters() {
+		if closer, ok := w.(io.Closer); ok {
+			if err := closer.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,62 @@
+package liblog
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+type closingBuffer struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (b *closingBuffer) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestShutdown(t *testing.T) {
+	defer quiet()()
+	logger := Init("shutdown")
+	var buf closingBuffer
+	logger.AddWriter(&buf)
+
+	logger.Info("before")
+	if err := logger.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("after")
+	logger.StopSync()
+
+	if !buf.closed {
+		t.Error("writer not closed")
+	}
+	if out := buf.String(); !strings.Contains(out, "before") || strings.Contains(out, "after") {
+		t.Errorf("unexpected output: %s", out)
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	defer quiet()()
+	var notices bytes.Buffer
+	log.SetOutput(&notices)
+	logger := Init("shutdown")
+	release := make(chan struct{})
+	logger.AddWriter(blockingWriter{release})
+	logger.Info("stuck")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := logger.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Shutdown returned %v", err)
+	}
+	close(release)
+	logger.StopSync()
+	if !strings.Contains(notices.String(), "shutdown interrupted") {
+		t.Errorf("timeout not reported: %q", notices.String())
+	}
+}
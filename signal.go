@@ -0,0 +1,64 @@
+package liblog
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+)
+
+// HandleSignals makes the logger write everything queued, as StopSync
+// does, when the process receives one of signals (SIGINT and SIGTERM if
+// none are given), then stops listening for them. What happens next is up
+// to the application: its own signal.Notify handlers receive the signal
+// as usual, and liblog neither delays nor repeats it. An application
+// without handlers of its own must enable SetSignalReraise, or the signal
+// no longer ends the process. The returned function removes the handler.
+func (logger *Logger) HandleSignals(signals ...os.Signal) (stop func()) {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	ch := make(chan os.Signal, 1)
+	quit := make(chan struct{})
+	signal.Notify(ch, signals...)
+	go func() {
+		select {
+		case sig := <-ch:
+			logger.StopSync()
+			signal.Stop(ch)
+			if atomic.LoadInt32(&logger.signalReraise) == 0 {
+				return
+			}
+			signal.Reset(sig)
+			if p, err := os.FindProcess(os.Getpid()); err == nil && p.Signal(sig) == nil {
+				return
+			}
+			os.Exit(1)
+		case <-quit:
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(quit)
+		})
+	}
+}
+
+// SetSignalReraise makes HandleSignals, once the logger is stopped, raise
+// the signal again with its default handling restored, so that the
+// process ends the way it would have without liblog, exit status
+// included. Where a signal cannot be raised again, the process exits with
+// status 1 instead. It is off by default, as restoring the default
+// handling is process-wide: handlers the application installed for the
+// same signals still receive the signal, but the process may be gone
+// before they are done.
+func (logger *Logger) SetSignalReraise(enable bool) {
+	var v int32
+	if enable {
+		v = 1
+	}
+	atomic.StoreInt32(&logger.signalReraise, v)
+}
@@ -0,0 +1,101 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package liblog
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestHandleSignals(t *testing.T) {
+	if os.Getenv("LIBLOG_SIGNAL_CHILD") == "1" {
+		logger := Init("signal")
+		// only written if the logger is stopped before the process ends
+		logger.SetStdoutBuffer(4096, time.Hour)
+		logger.SetSignalReraise(true)
+		logger.HandleSignals()
+		logger.Info("buffered before the signal")
+		syscall.Kill(os.Getpid(), syscall.SIGTERM)
+		time.Sleep(5 * time.Second)
+		os.Exit(0)
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=TestHandleSignals$")
+	cmd.Env = append(os.Environ(), "LIBLOG_SIGNAL_CHILD=1", "LOGOUTPUT=stdout")
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	err := cmd.Run()
+
+	status, ok := cmd.ProcessState.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() || status.Signal() != syscall.SIGTERM {
+		t.Errorf("child not killed by SIGTERM: %v", err)
+	}
+	if !strings.Contains(stdout.String(), `"message":"buffered before the signal"`) {
+		t.Errorf("buffered message lost: %q", stdout.String())
+	}
+}
+
+func TestHandleSignalsComposable(t *testing.T) {
+	if os.Getenv("LIBLOG_SIGNAL_CHILD") == "1" {
+		logger := Init("signal")
+		logger.SetStdoutBuffer(4096, time.Hour)
+		own := make(chan os.Signal, 1)
+		signal.Notify(own, syscall.SIGTERM)
+		logger.HandleSignals()
+		logger.Info("buffered before the signal")
+		syscall.Kill(os.Getpid(), syscall.SIGTERM)
+		<-own
+		// give liblog's handler the time to stop the logger
+		time.Sleep(500 * time.Millisecond)
+		os.Stdout.WriteString("handled by the application\n")
+		os.Exit(3)
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=TestHandleSignalsComposable$")
+	cmd.Env = append(os.Environ(), "LIBLOG_SIGNAL_CHILD=1", "LOGOUTPUT=stdout")
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Run()
+
+	if code := cmd.ProcessState.ExitCode(); code != 3 {
+		t.Errorf("child exited with %d, want the application's 3", code)
+	}
+	out := stdout.String()
+	if !strings.Contains(out, `"message":"buffered before the signal"`) || !strings.HasSuffix(out, "handled by the application\n") {
+		t.Errorf("unexpected output: %q", out)
+	}
+}
+
+func TestReopenOnSignal(t *testing.T) {
+	defer quiet()()
+	logger := Init("reopen", WithSynchronous())
+	defer logger.StopSync()
+	w := &reopenNotifier{reopened: make(chan struct{}, 1)}
+	logger.AddWriter(w)
+	stop := logger.ReopenOnSignal()
+	defer stop()
+
+	syscall.Kill(os.Getpid(), syscall.SIGHUP)
+	select {
+	case <-w.reopened:
+	case <-time.After(5 * time.Second):
+		t.Fatal("not reopened on SIGHUP")
+	}
+}
+
+type reopenNotifier struct {
+	bytes.Buffer
+	reopened chan struct{}
+}
+
+func (w *reopenNotifier) Reopen() error {
+	w.reopened <- struct{}{}
+	return nil
+}
@@ -0,0 +1,38 @@
+package liblog
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+// SetCaptureFunction adds the name of the logging function, e.g.
+// "main.handler", to the source location as src_func, or as the function
+// key of the nested source object. Looking it up costs a little on every
+// call, so it is off by default.
+func (logger *Logger) SetCaptureFunction(enable bool) {
+	var flag int32
+	if enable {
+		flag = 1
+	}
+	atomic.StoreInt32(&logger.captureFunc, flag)
+}
+
+// SetNestedSource writes the source location as one nested object,
+// e.g. "source":{"file":"handler.go","line":42,"function":"main.handler"},
+// as ECS and GCP schemas expect, instead of flat src_file and src_line
+// keys. Formats without nesting, such as logfmt, use dotted keys.
+func (logger *Logger) SetNestedSource(enable bool) {
+	logger.mu.Lock()
+	logger.format.NestedSource = enable
+	logger.mu.Unlock()
+}
+
+func (logger *Logger) funcName(pc uintptr) string {
+	if atomic.LoadInt32(&logger.captureFunc) == 0 {
+		return ""
+	}
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		return fn.Name()
+	}
+	return ""
+}
@@ -0,0 +1,44 @@
+package liblog
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestNestedSource(t *testing.T) {
+	defer quiet()()
+	logger := Init("source")
+	defer logger.StopSync()
+
+	logger.SetNestedSource(true)
+	out := logger.Render(InfoLevel, "msg")
+	if strings.Contains(string(out), `"function"`) || strings.Contains(string(out), "src_file") {
+		t.Errorf("unexpected keys: %s", out)
+	}
+
+	logger.SetCaptureFunction(true)
+	var msg struct {
+		Source struct {
+			File     string `json:"file"`
+			Line     int    `json:"line"`
+			Function string `json:"function"`
+		} `json:"source"`
+	}
+	json.Unmarshal(logger.Render(InfoLevel, "msg"), &msg)
+	if msg.Source.File != "source_test.go" || msg.Source.Line == 0 ||
+		msg.Source.Function != "github.com/wimark/liblog.TestNestedSource" {
+		t.Errorf("source = %+v", msg.Source)
+	}
+}
+
+func TestCaptureFunctionFlat(t *testing.T) {
+	defer quiet()()
+	logger := Init("source")
+	defer logger.StopSync()
+
+	logger.SetCaptureFunction(true)
+	if out := logger.Render(InfoLevel, "msg"); !strings.Contains(string(out), `"src_func":"github.com/wimark/liblog.TestCaptureFunctionFlat"`) {
+		t.Errorf("src_func missing: %s", out)
+	}
+}
@@ -0,0 +1,34 @@
+package liblog
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Span times a block of code, see StartSpan.
+type Span struct {
+	logger *Logger
+	name   string
+	start  time.Time
+	ended  int32
+}
+
+// StartSpan logs a "span started" record at Debug level with a "span"
+// field and returns a span whose End logs the time elapsed since:
+//
+//	defer logger.StartSpan("load config").End()
+func (logger *Logger) StartSpan(name string) *Span {
+	logger.log(DebugLevel, []Field{{"span", name}}, "span started")
+	return &Span{logger: logger, name: name, start: time.Now()}
+}
+
+// End logs a "span ended" record at Info level with the "span" and a
+// "duration_ms" field. Only the first call logs, so a deferred End can be
+// combined with an earlier one on some paths.
+func (s *Span) End() {
+	if !atomic.CompareAndSwapInt32(&s.ended, 0, 1) {
+		return
+	}
+	ms := float64(time.Since(s.start)) / float64(time.Millisecond)
+	s.logger.log(InfoLevel, []Field{{"span", s.name}, Float("duration_ms", ms, 3)}, "span ended")
+}
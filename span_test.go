@@ -0,0 +1,44 @@
+package liblog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSpan(t *testing.T) {
+	defer quiet()()
+	logger := Init("span")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+	logger.SetLevel(DebugLevel)
+
+	func() {
+		span := logger.StartSpan("load")
+		defer span.End()
+		time.Sleep(10 * time.Millisecond)
+		span.End()
+	}()
+	logger.StopSync()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d records, want 2: %s", len(lines), buf.String())
+	}
+	if !strings.Contains(lines[0], `"level":"DEBUG","message":"span started"`) || !strings.Contains(lines[0], `"span":"load"`) {
+		t.Errorf("unexpected start record: %s", lines[0])
+	}
+	var end struct {
+		Level    string
+		Span     string
+		Duration float64 `json:"duration_ms"`
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &end); err != nil {
+		t.Fatal(err)
+	}
+	if end.Level != "INFO" || end.Span != "load" || end.Duration < 10 {
+		t.Errorf("unexpected end record: %s", lines[1])
+	}
+}
@@ -0,0 +1,142 @@
+package liblog
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// DefaultMaxStackDepth is the number of frames written by ErrorStack and
+// Recover unless SetMaxStackDepth says otherwise.
+const DefaultMaxStackDepth = 32
+
+// StackFrame is one frame of a structured stack, see SetStructuredStacks.
+type StackFrame struct {
+	Func string `json:"func"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+// SetStructuredStacks makes ErrorStack and Recover write the "stack" field
+// as an array of {"func","file","line"} objects instead of the default
+// multiline string, so that log stores can index individual frames.
+func (logger *Logger) SetStructuredStacks(structured bool) {
+	var v int32
+	if structured {
+		v = 1
+	}
+	atomic.StoreInt32(&logger.structuredStacks, v)
+}
+
+// SetMaxStackDepth limits the frames written by ErrorStack and Recover to
+// the innermost n; n <= 0 restores DefaultMaxStackDepth.
+func (logger *Logger) SetMaxStackDepth(n int) {
+	atomic.StoreInt64(&logger.maxStackDepth, int64(n))
+}
+
+// ErrorStack logs at Error level with a "stack" field holding the stack of
+// the calling goroutine, starting at the caller.
+func (logger *Logger) ErrorStack(format string, values ...interface{}) {
+	logger.log(ErrorLevel, []Field{logger.stackField(stackFrames(3, logger.stackDepth()))}, format, values...)
+}
+
+// Recover, when deferred, recovers a panic and logs it at Error level with
+// the stack of the panicking goroutine, starting where the panic occurred.
+// The panic does not go any further: the deferring function returns
+// normally with its results as they were when it panicked.
+func (logger *Logger) Recover() {
+	if r := recover(); r != nil {
+		logger.logPanic(r)
+	}
+}
+
+func (logger *Logger) logPanic(r interface{}) {
+	frames := stackFrames(4, -1)
+	for i, frame := range frames {
+		if frame.Func == "runtime.gopanic" {
+			frames = frames[i+1:]
+			break
+		}
+	}
+	if depth := logger.stackDepth(); len(frames) > depth {
+		frames = frames[:depth]
+	}
+	msg := logger.message(0, ErrorLevel, []Field{logger.stackField(frames)}, "panic: %v", r)
+	// runtime errors start in the runtime: point at the code that caused them
+	for _, frame := range frames {
+		if !strings.HasPrefix(frame.Func, "runtime.") {
+			msg.SrcFile = frame.File[strings.LastIndexByte(frame.File, '/')+1:]
+			msg.SrcLine = frame.Line
+			if atomic.LoadInt32(&logger.captureFunc) != 0 {
+				msg.SrcFunc = frame.Func
+			}
+			break
+		}
+	}
+	logger.send(msg)
+}
+
+func (logger *Logger) stackDepth() int {
+	if n := atomic.LoadInt64(&logger.maxStackDepth); n > 0 {
+		return int(n)
+	}
+	return DefaultMaxStackDepth
+}
+
+func (logger *Logger) stackField(frames []StackFrame) Field {
+	if atomic.LoadInt32(&logger.structuredStacks) != 0 {
+		return Field{"stack", frames}
+	}
+	var b strings.Builder
+	for i, frame := range frames {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(frame.Func)
+		b.WriteString("\n\t")
+		b.WriteString(frame.File)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(frame.Line))
+	}
+	return Field{"stack", b.String()}
+}
+
+// stackFrames returns at most max frames (all of them if max < 0) of the
+// calling goroutine, skipping skip frames as runtime.Callers does.
+func stackFrames(skip, max int) []StackFrame {
+	pcs := make([]uintptr, 64)
+	for {
+		n := runtime.Callers(skip, pcs)
+		if n < len(pcs) || (max >= 0 && n >= max) {
+			pcs = pcs[:n]
+			break
+		}
+		pcs = make([]uintptr, 2*len(pcs))
+	}
+	frames := make([]StackFrame, 0, len(pcs))
+	iter := runtime.CallersFrames(pcs)
+	for {
+		frame, more := iter.Next()
+		if max >= 0 && len(frames) == max {
+			break
+		}
+		frames = append(frames, StackFrame{frame.Function, frame.File, frame.Line})
+		if !more {
+			break
+		}
+	}
+	return frames
+}
+
+func ErrorStack(format string, values ...interface{}) {
+	if singleLogger != nil {
+		singleLogger.log(ErrorLevel, []Field{singleLogger.stackField(stackFrames(3, singleLogger.stackDepth()))}, format, values...)
+	}
+}
+
+func Recover() {
+	if r := recover(); r != nil && singleLogger != nil {
+		singleLogger.logPanic(r)
+	}
+}
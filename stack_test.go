@@ -0,0 +1,77 @@
+package liblog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestErrorStack(t *testing.T) {
+	defer quiet()()
+	logger := Init("stack")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+
+	logger.ErrorStack("failed")
+	logger.SetStructuredStacks(true)
+	logger.SetMaxStackDepth(2)
+	logger.ErrorStack("failed again")
+	logger.StopSync()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var raw struct{ Stack string }
+	if err := json.Unmarshal([]byte(lines[0]), &raw); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(raw.Stack, "github.com/wimark/liblog.TestErrorStack\n\t") {
+		t.Errorf("raw stack does not start at the caller: %q", raw.Stack)
+	}
+	var structured struct{ Stack []StackFrame }
+	if err := json.Unmarshal([]byte(lines[1]), &structured); err != nil {
+		t.Fatal(err)
+	}
+	if len(structured.Stack) != 2 {
+		t.Fatalf("got %d frames, want 2: %s", len(structured.Stack), lines[1])
+	}
+	if f := structured.Stack[0]; f.Func != "github.com/wimark/liblog.TestErrorStack" || !strings.HasSuffix(f.File, "stack_test.go") || f.Line == 0 {
+		t.Errorf("unexpected first frame %+v", f)
+	}
+}
+
+func panics() {
+	var m map[string]int
+	m["x"] = 1
+}
+
+func TestRecover(t *testing.T) {
+	defer quiet()()
+	logger := Init("stack")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+	logger.SetStructuredStacks(true)
+
+	func() {
+		defer logger.Recover()
+		panics()
+	}()
+	logger.StopSync()
+
+	var msg struct {
+		Message string
+		SrcFile string `json:"src_file"`
+		Stack   []StackFrame
+	}
+	if err := json.Unmarshal(buf.Bytes(), &msg); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(msg.Message, "panic: assignment to entry in nil map") {
+		t.Errorf("unexpected message %q", msg.Message)
+	}
+	if len(msg.Stack) < 2 || !strings.HasPrefix(msg.Stack[0].Func, "runtime.") || msg.Stack[1].Func != "github.com/wimark/liblog.panics" {
+		t.Errorf("stack does not start at the panic: %+v", msg)
+	}
+	if msg.SrcFile != "stack_test.go" {
+		t.Errorf("source not set to the panicking code: %+v", msg)
+	}
+}
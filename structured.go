@@ -0,0 +1,46 @@
+package liblog
+
+import (
+	"fmt"
+	"io"
+)
+
+// StructuredWriter is implemented by writers that want messages rather
+// than formatted records, e.g. to export metrics or fill a database
+// without parsing JSON back. When a writer added with AddWriter or
+// AddRoute (or set as the output) implements it, the worker calls WriteMsg
+// instead of Write with every message it would have written, after
+// truncation and splitting.
+//
+// msg is a copy made for the call, but its Fields are shared with the
+// logger and other writers: WriteMsg must not modify them, and must copy
+// what it keeps after returning instead of retaining msg. Records the
+// worker only has as bytes, like those held by EnableErrorContextBuffer,
+// still go through Write. An error is handled as a failed write, without
+// retries.
+type StructuredWriter interface {
+	WriteMsg(msg *LogMsg) error
+}
+
+// writeTo writes the record to w, as a message if w is a StructuredWriter
+// and the message is known, and reports whether it succeeded.
+func (logger *Logger) writeTo(w io.Writer, rec *record) bool {
+	if sw, ok := w.(StructuredWriter); ok && rec.msg != nil {
+		return writeMsgSafe(sw, *rec.msg) == nil
+	}
+	if fw, ok := w.(*formattedWriter); ok {
+		data, ok := rec.bytesFor(fw.format)
+		return ok && logger.write(fw.Writer, data)
+	}
+	return logger.write(w, rec.data)
+}
+
+func writeMsgSafe(w StructuredWriter, msg LogMsg) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			internalf("writer %T panicked: %v", w, r)
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return w.WriteMsg(&msg)
+}
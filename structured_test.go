@@ -0,0 +1,41 @@
+package liblog
+
+import (
+	"bytes"
+	"testing"
+)
+
+type msgSink struct {
+	msgs  []LogMsg
+	bytes bytes.Buffer
+}
+
+func (s *msgSink) Write(p []byte) (int, error) { return s.bytes.Write(p) }
+
+func (s *msgSink) WriteMsg(msg *LogMsg) error {
+	s.msgs = append(s.msgs, *msg)
+	msg.Message = "changed by the sink"
+	return nil
+}
+
+func TestStructuredWriter(t *testing.T) {
+	defer quiet()()
+	logger := Init("structured")
+	sink := &msgSink{}
+	var plain bytes.Buffer
+	logger.AddWriter(sink)
+	logger.AddWriter(&plain)
+
+	logger.InfoKV("saved", "id", 7)
+	logger.StopSync()
+
+	if sink.bytes.Len() != 0 {
+		t.Errorf("structured writer got bytes: %s", sink.bytes.String())
+	}
+	if len(sink.msgs) != 1 || sink.msgs[0].Message != "saved" || sink.msgs[0].Fields[0] != (Field{"id", 7}) {
+		t.Fatalf("unexpected messages %+v", sink.msgs)
+	}
+	if !bytes.Contains(plain.Bytes(), []byte(`"message":"saved"`)) {
+		t.Errorf("other writer affected by the sink: %s", plain.String())
+	}
+}
@@ -0,0 +1,49 @@
+package liblog
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// SetSyncFromLevel makes messages at level or above bypass the queue: the
+// logging goroutine writes them itself before the log call returns, so
+// that they are neither dropped nor delayed, e.g. SetSyncFromLevel(ErrorLevel).
+// Lower levels stay asynchronous. Writes are serialized with the worker,
+// and the stdout buffer (see SetStdoutBuffer) is flushed after each.
+//
+// Before writing, the logging goroutine writes the messages already
+// queued, so a synchronous message comes after the messages logged
+// before it, with two exceptions: the message the worker is about to
+// write when the call is made, and messages held by SetAdaptiveQueue,
+// may come after it. It has no effect on synchronous loggers.
+func (logger *Logger) SetSyncFromLevel(level LogLevel) {
+	atomic.StoreInt32(&logger.syncFrom, int32(level))
+}
+
+// DisableSyncFromLevel makes every message go through the queue again.
+func (logger *Logger) DisableSyncFromLevel() {
+	atomic.StoreInt32(&logger.syncFrom, math.MaxInt32)
+}
+
+func (logger *Logger) syncFor(level LogLevel) bool {
+	return int32(level) >= atomic.LoadInt32(&logger.syncFrom)
+}
+
+// writeInline writes msg from the logging goroutine after the queued
+// messages. logger.closeMu must be read-locked, so that output is open.
+func (logger *Logger) writeInline(msg LogMsg) {
+	logger.syncMu.Lock()
+	defer logger.syncMu.Unlock()
+	for n := len(logger.output); n > 0; n-- {
+		select {
+		case queued := <-logger.output:
+			logger.printMessage(queued)
+		default:
+			n = 0
+		}
+	}
+	logger.printMessage(msg)
+	if buffer := logger.stdoutBuffer(); buffer != nil {
+		buffer.flush()
+	}
+}
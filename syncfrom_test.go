@@ -0,0 +1,29 @@
+package liblog
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSyncFromLevel(t *testing.T) {
+	defer quiet()()
+	os.Setenv("LOG_QUEUE_LEN", "10")
+	defer os.Unsetenv("LOG_QUEUE_LEN")
+
+	logger := Init("syncfrom")
+	var capture syncBuffer
+	logger.AddWriter(&capture)
+	logger.SetSyncFromLevel(ErrorLevel)
+
+	logger.Info("queued 1")
+	logger.Info("queued 2")
+	logger.Error("inline")
+	if out := capture.String(); !strings.Contains(out, `"message":"inline"`) {
+		t.Fatalf("error not written before Error returned: %s", out)
+	}
+	logger.StopSync()
+	if n := strings.Count(capture.String(), "\n"); n != 3 {
+		t.Errorf("got %d records, want 3: %s", n, capture.String())
+	}
+}
@@ -0,0 +1,42 @@
+package liblog
+
+import "io"
+
+// TeeWriter duplicates writes to all of its writers. Unlike io.MultiWriter
+// it does not stop at the first failing writer.
+type TeeWriter struct {
+	writers []io.Writer
+	// OnError, when set, is called for every writer that fails a write.
+	OnError func(w io.Writer, err error)
+}
+
+func NewTeeWriter(writers ...io.Writer) *TeeWriter {
+	return &TeeWriter{writers: append([]io.Writer(nil), writers...)}
+}
+
+// Write writes p to every writer. It only fails when none of them
+// accepted p, returning the first error.
+func (tee *TeeWriter) Write(p []byte) (n int, err error) {
+	var firstErr error
+	failed := 0
+	for _, w := range tee.writers {
+		written, err := w.Write(p)
+		if err == nil && written < len(p) {
+			err = io.ErrShortWrite
+		}
+		if err == nil {
+			continue
+		}
+		failed++
+		if firstErr == nil {
+			firstErr = err
+		}
+		if tee.OnError != nil {
+			tee.OnError(w, err)
+		}
+	}
+	if failed > 0 && failed == len(tee.writers) {
+		return 0, firstErr
+	}
+	return len(p), nil
+}
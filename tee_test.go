@@ -0,0 +1,41 @@
+package liblog
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+type failingWriter struct{ err error }
+
+func (w failingWriter) Write(p []byte) (int, error) { return 0, w.err }
+
+func TestTeeWriter(t *testing.T) {
+	var first, last bytes.Buffer
+	broken := failingWriter{errors.New("disk full")}
+	tee := NewTeeWriter(&first, broken, &last)
+	var reported []error
+	tee.OnError = func(w io.Writer, err error) {
+		if w != io.Writer(broken) {
+			t.Errorf("error reported for the wrong writer %v", w)
+		}
+		reported = append(reported, err)
+	}
+
+	n, err := tee.Write([]byte("line\n"))
+	if n != 5 || err != nil {
+		t.Errorf("Write = %d, %v", n, err)
+	}
+	if first.String() != "line\n" || last.String() != "line\n" {
+		t.Errorf("healthy writers got %q and %q", first.String(), last.String())
+	}
+	if len(reported) != 1 || reported[0] != broken.err {
+		t.Errorf("reported errors %v", reported)
+	}
+
+	n, err = NewTeeWriter(broken, broken).Write([]byte("line\n"))
+	if n != 0 || err != broken.err {
+		t.Errorf("Write with only failing writers = %d, %v", n, err)
+	}
+}
@@ -0,0 +1,167 @@
+package liblog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// LogfmtFormatter writes every message as a line of key=value pairs, with
+// the same keys as the JSON formatter.
+type LogfmtFormatter struct{}
+
+func (LogfmtFormatter) Format(buf *bytes.Buffer, msg *LogMsg, opts FormatOptions) error {
+	buf.WriteString("timestamp=")
+	var scratch [64]byte
+	ts, _ := appendTime(scratch[:0], msg.Timestamp, opts.TimeFormat)
+	buf.Write(ts)
+	label, number, severity := opts.SeverityOf(msg.Level)
+	writeLogfmtPair(buf, "level", label)
+	if severity {
+		writeLogfmtPair(buf, "severity", strconv.Itoa(number))
+	}
+	if opts.LevelNumber {
+		writeLogfmtPair(buf, "levelno", strconv.Itoa(int(msg.Level)))
+	}
+	if msg.Message != "" || !opts.OmitEmptyMessage {
+		writeLogfmtPair(buf, "message", msg.Message)
+	}
+	if msg.Module != "" {
+		writeLogfmtPair(buf, "service", msg.Module)
+	}
+	if msg.ModuleId != "" {
+		writeLogfmtPair(buf, "service_id", msg.ModuleId)
+	}
+	if msg.Logger != "" {
+		writeLogfmtPair(buf, "logger", msg.Logger)
+	}
+	if opts.NestedSource {
+		if msg.SrcFile != "" {
+			writeLogfmtPair(buf, "source.file", msg.SrcFile)
+			writeLogfmtPair(buf, "source.line", strconv.Itoa(msg.SrcLine))
+		}
+		if msg.SrcFunc != "" {
+			writeLogfmtPair(buf, "source.function", msg.SrcFunc)
+		}
+	} else if opts.CallerField {
+		if msg.SrcFile != "" {
+			writeLogfmtPair(buf, "caller", msg.SrcFile+":"+strconv.Itoa(msg.SrcLine))
+		}
+	} else {
+		if msg.SrcFile != "" {
+			writeLogfmtPair(buf, "src_file", msg.SrcFile)
+		}
+		if msg.SrcLine != 0 {
+			writeLogfmtPair(buf, "src_line", strconv.Itoa(msg.SrcLine))
+		}
+		if msg.SrcFunc != "" {
+			writeLogfmtPair(buf, "src_func", msg.SrcFunc)
+		}
+	}
+	if msg.GoroutineId != 0 {
+		writeLogfmtPair(buf, "goid", strconv.FormatUint(msg.GoroutineId, 10))
+	}
+	for _, field := range msg.Fields {
+		writeLogfmtPair(buf, field.Key, fieldString(field.Value))
+	}
+	buf.WriteString(opts.Terminator())
+	return nil
+}
+
+func writeLogfmtPair(buf *bytes.Buffer, key, value string) {
+	buf.WriteByte(' ')
+	buf.WriteString(key)
+	buf.WriteByte('=')
+	writeLogfmtValue(buf, value)
+}
+
+func writeLogfmtValue(buf *bytes.Buffer, value string) {
+	if value == "" {
+		buf.WriteString(`""`)
+		return
+	}
+	for i := 0; i < len(value); i++ {
+		if b := value[i]; b <= ' ' || b == '=' || b == '"' || b == 0x7f {
+			buf.WriteString(strconv.Quote(value))
+			return
+		}
+	}
+	buf.WriteString(value)
+}
+
+// fieldString renders a field value for the text formats: strings as they
+// are, anything else like its JSON representation.
+func fieldString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// ConsoleFormatter writes messages in a format meant for people reading a
+// terminal:
+//
+//	2021-03-04 10:11:12.345 WARNING api auth: token expired user=7 (jwt.go:42)
+type ConsoleFormatter struct {
+	// Color highlights levels with ANSI escape sequences
+	Color bool
+}
+
+var levelColors = map[LogLevel]string{
+	DebugLevel:   "\x1b[90m",
+	InfoLevel:    "\x1b[32m",
+	WarningLevel: "\x1b[33m",
+	ErrorLevel:   "\x1b[31m",
+	FatalLevel:   "\x1b[1;31m",
+}
+
+func (f ConsoleFormatter) Format(buf *bytes.Buffer, msg *LogMsg, opts FormatOptions) error {
+	buf.WriteString(msg.Timestamp.Format("2006-01-02 15:04:05.000"))
+	buf.WriteByte(' ')
+	color := levelColors[msg.Level]
+	if f.Color && color != "" {
+		buf.WriteString(color)
+	}
+	fmt.Fprintf(buf, "%-7s", msg.Level.String())
+	if f.Color && color != "" {
+		buf.WriteString("\x1b[0m")
+	}
+	buf.WriteByte(' ')
+	buf.WriteString(msg.Module)
+	if msg.ModuleId != "" {
+		buf.WriteByte('[')
+		buf.WriteString(msg.ModuleId)
+		buf.WriteByte(']')
+	}
+	if msg.Logger != "" {
+		buf.WriteByte(' ')
+		buf.WriteString(msg.Logger)
+	}
+	buf.WriteString(": ")
+	buf.WriteString(msg.Message)
+	if msg.GoroutineId != 0 {
+		writeLogfmtPair(buf, "goid", strconv.FormatUint(msg.GoroutineId, 10))
+	}
+	for _, field := range msg.Fields {
+		writeLogfmtPair(buf, field.Key, fieldString(field.Value))
+	}
+	if msg.SrcFile != "" {
+		buf.WriteString(" (")
+		buf.WriteString(msg.SrcFile)
+		buf.WriteByte(':')
+		buf.WriteString(strconv.Itoa(msg.SrcLine))
+		buf.WriteByte(')')
+	}
+	buf.WriteString(opts.Terminator())
+	return nil
+}
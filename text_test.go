@@ -0,0 +1,87 @@
+package liblog
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var textMsg = LogMsg{
+	Timestamp: time.Date(2021, 3, 4, 10, 11, 12, 345000000, time.UTC),
+	Level:     WarningLevel,
+	Message:   `token "abc" expired`,
+	Module:    "api",
+	Logger:    "auth",
+	SrcFile:   "jwt.go",
+	SrcLine:   42,
+	Fields:    []Field{Any("user", 7), Any("err", errors.New("bad sig")), Any("empty", "")},
+}
+
+func TestLogfmtFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	LogfmtFormatter{}.Format(&buf, &textMsg, FormatOptions{})
+	want := `timestamp=2021-03-04T10:11:12.345Z level=WARNING message="token \"abc\" expired" service=api logger=auth src_file=jwt.go src_line=42 user=7 err="bad sig" empty=""` + "\n"
+	if buf.String() != want {
+		t.Errorf("got  %s\nwant %s", buf.String(), want)
+	}
+}
+
+func TestConsoleFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	ConsoleFormatter{}.Format(&buf, &textMsg, FormatOptions{})
+	want := `2021-03-04 10:11:12.345 WARNING api auth: token "abc" expired user=7 err="bad sig" empty="" (jwt.go:42)` + "\n"
+	if buf.String() != want {
+		t.Errorf("got  %s\nwant %s", buf.String(), want)
+	}
+
+	buf.Reset()
+	ConsoleFormatter{Color: true}.Format(&buf, &textMsg, FormatOptions{})
+	if !bytes.Contains(buf.Bytes(), []byte("\x1b[33mWARNING\x1b[0m")) {
+		t.Errorf("level not colored: %q", buf.String())
+	}
+}
+
+func TestSetFormatterWhileLogging(t *testing.T) {
+	defer quiet()()
+	logger := Init("swap")
+	var buf syncBuffer
+	logger.AddWriter(&buf)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			logger.Info("message %d", i)
+		}
+	}()
+	formatters := []Formatter{JSONFormatter{}, LogfmtFormatter{}, ConsoleFormatter{Color: true}}
+	for i := 0; i < 200; i++ {
+		logger.SetFormatter(formatters[i%len(formatters)])
+	}
+	<-done
+	logger.StopSync()
+	if n := bytes.Count([]byte(buf.String()), []byte("\n")); n != 200 {
+		t.Errorf("%d records written, want 200", n)
+	}
+}
+
+func TestPostFormatHook(t *testing.T) {
+	defer quiet()()
+	logger := Init("hook", WithSynchronous())
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+	var seen []string
+	logger.SetPostFormatHook(func(level LogLevel, formatted []byte) {
+		seen = append(seen, level.String()+" "+string(formatted))
+	})
+	logger.Warning("hooked")
+	logger.SetPostFormatHook(nil)
+	logger.Info("not hooked")
+	logger.StopSync()
+
+	if len(seen) != 1 || seen[0] != "WARNING "+strings.SplitAfter(buf.String(), "\n")[0] {
+		t.Errorf("hook saw %q, output %q", seen, buf.String())
+	}
+}
@@ -0,0 +1,141 @@
+package liblog
+
+import (
+	"container/list"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultThrottleCacheSize is the number of throttle keys a logger
+// remembers unless SetThrottleCacheSize says otherwise.
+const DefaultThrottleCacheSize = 4096
+
+// throttle tracks per-key state for LogEvery, LogFirstN and LogEveryN in
+// an LRU so that high-cardinality keys cannot grow it without bound.
+type throttle struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	lru     list.List
+}
+
+type throttleEntry struct {
+	key      string
+	lastEmit time.Time
+	count    int
+}
+
+// entry returns the state for key, marking it most recently used and
+// evicting the least recently used key when the cache is full.
+// t.mu must be held.
+func (t *throttle) entry(key string) *throttleEntry {
+	if e, ok := t.entries[key]; ok {
+		t.lru.MoveToFront(e)
+		return e.Value.(*throttleEntry)
+	}
+	if t.entries == nil {
+		t.entries = make(map[string]*list.Element)
+	}
+	size := t.size
+	if size <= 0 {
+		size = DefaultThrottleCacheSize
+	}
+	for t.lru.Len() >= size {
+		oldest := t.lru.Back()
+		delete(t.entries, oldest.Value.(*throttleEntry).key)
+		t.lru.Remove(oldest)
+	}
+	ent := &throttleEntry{key: key}
+	t.entries[key] = t.lru.PushFront(ent)
+	return ent
+}
+
+func (t *throttle) setSize(n int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.size = n
+	if n <= 0 {
+		n = DefaultThrottleCacheSize
+	}
+	for t.lru.Len() > n {
+		oldest := t.lru.Back()
+		delete(t.entries, oldest.Value.(*throttleEntry).key)
+		t.lru.Remove(oldest)
+	}
+}
+
+func (t *throttle) getSize() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.size
+}
+
+// allow reports whether d has passed since the last allowed call for key.
+func (t *throttle) allow(key string, d time.Duration) bool {
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e := t.entry(key)
+	if !e.lastEmit.IsZero() && now.Sub(e.lastEmit) < d {
+		return false
+	}
+	e.lastEmit = now
+	return true
+}
+
+// count returns how many times count was called for key, this call included.
+func (t *throttle) count(key string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e := t.entry(key)
+	e.count++
+	return e.count
+}
+
+// SetThrottleCacheSize limits how many distinct keys LogEvery, LogEveryKey,
+// LogFirstN and LogEveryN remember; n <= 0 restores
+// DefaultThrottleCacheSize. When the limit is reached the least recently
+// seen key is evicted and its state starts over: an evicted LogEvery key
+// is logged again on its next call and an evicted LogFirstN/LogEveryN
+// call site counts from one again.
+func (logger *Logger) SetThrottleCacheSize(n int) {
+	logger.throttle.setSize(n)
+}
+
+func callSite(skip int) string {
+	_, file, line, _ := runtime.Caller(skip + 1)
+	return file + ":" + strconv.Itoa(line)
+}
+
+// LogEvery logs the message unless a message with the same format string
+// was logged through LogEvery less than d ago.
+func (logger *Logger) LogEvery(d time.Duration, level LogLevel, format string, values ...interface{}) {
+	if logger.throttle.allow(format, d) {
+		logger.log(level, nil, format, values...)
+	}
+}
+
+// LogEveryKey is like LogEvery but throttles by key rather than by
+// format string, e.g. to limit the same error per tenant.
+func (logger *Logger) LogEveryKey(key string, d time.Duration, level LogLevel, format string, values ...interface{}) {
+	if logger.throttle.allow(key, d) {
+		logger.log(level, nil, format, values...)
+	}
+}
+
+// LogFirstN logs only the first n messages logged from the calling line.
+func (logger *Logger) LogFirstN(n int, level LogLevel, format string, values ...interface{}) {
+	if logger.throttle.count(callSite(1)) <= n {
+		logger.log(level, nil, format, values...)
+	}
+}
+
+// LogEveryN logs the 1st, (n+1)th, (2n+1)th... message logged from the
+// calling line.
+func (logger *Logger) LogEveryN(n int, level LogLevel, format string, values ...interface{}) {
+	if n <= 1 || (logger.throttle.count(callSite(1))-1)%n == 0 {
+		logger.log(level, nil, format, values...)
+	}
+}
@@ -0,0 +1,88 @@
+package liblog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogEvery(t *testing.T) {
+	defer quiet()()
+	logger := Init("throttle")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+
+	for i := 0; i < 10; i++ {
+		logger.LogEvery(time.Hour, WarningLevel, "disk almost full: %d%%", 90+i)
+		logger.LogEvery(time.Hour, InfoLevel, "other site")
+	}
+	logger.LogEvery(0, InfoLevel, "other site")
+	logger.StopSync()
+
+	out := buf.String()
+	if n := strings.Count(out, "disk almost full"); n != 1 {
+		t.Errorf("throttled message logged %d times", n)
+	}
+	if !strings.Contains(out, "disk almost full: 90%") {
+		t.Errorf("first occurrence not logged: %s", out)
+	}
+	if n := strings.Count(out, "other site"); n != 2 {
+		t.Errorf("other message logged %d times, want 2", n)
+	}
+}
+
+func TestLogFirstNAndEveryN(t *testing.T) {
+	defer quiet()()
+	logger := Init("throttle")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+
+	for i := 0; i < 10; i++ {
+		logger.LogFirstN(3, InfoLevel, "first %d", i)
+		logger.LogFirstN(1, InfoLevel, "first %d", i)
+		logger.LogEveryN(4, InfoLevel, "every %d", i)
+	}
+	logger.StopSync()
+
+	out := buf.String()
+	for _, want := range []string{"first 0", "first 1", "first 2", "every 0", "every 4", "every 8"} {
+		if !strings.Contains(out, `"`+want+`"`) {
+			t.Errorf("%q not logged", want)
+		}
+	}
+	// the second LogFirstN call site is counted separately
+	if n := strings.Count(out, `"first 0"`); n != 2 {
+		t.Errorf("first 0 logged %d times, want 2", n)
+	}
+	if n := strings.Count(out, "\n"); n != 7 {
+		t.Errorf("%d messages logged, want 7:\n%s", n, out)
+	}
+}
+
+func TestThrottleCacheEviction(t *testing.T) {
+	defer quiet()()
+	logger := Init("throttle")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+	logger.SetThrottleCacheSize(2)
+
+	logger.LogEveryKey("tenant-a", time.Hour, ErrorLevel, "failed for a")
+	logger.LogEveryKey("tenant-b", time.Hour, ErrorLevel, "failed for b")
+	logger.LogEveryKey("tenant-a", time.Hour, ErrorLevel, "failed for a")
+	// evicts tenant-b, the least recently seen key
+	logger.LogEveryKey("tenant-c", time.Hour, ErrorLevel, "failed for c")
+	logger.LogEveryKey("tenant-a", time.Hour, ErrorLevel, "failed for a")
+	logger.LogEveryKey("tenant-b", time.Hour, ErrorLevel, "failed for b")
+	logger.StopSync()
+
+	out := buf.String()
+	for want, n := range map[string]int{"failed for a": 1, "failed for b": 2, "failed for c": 1} {
+		if got := strings.Count(out, want); got != n {
+			t.Errorf("%q logged %d times, want %d", want, got, n)
+		}
+	}
+	if l := logger.throttle.lru.Len(); l != 2 {
+		t.Errorf("cache holds %d keys, want 2", l)
+	}
+}
@@ -0,0 +1,51 @@
+package liblog
+
+import "time"
+
+// LogWithTimeout logs a message if it can be queued within d, and reports
+// whether it was. Unlike the other log functions it never waits longer
+// than d for a full queue, even without LOG_QUEUE_LEN. A message given up
+// on counts as dropped. Messages are queued before the level filter, so a
+// filtered message is reported as queued.
+func (logger *Logger) LogWithTimeout(d time.Duration, level LogLevel, format string, values ...interface{}) bool {
+	return logger.sendTimeout(logger.message(2, level, nil, format, values...), d)
+}
+
+func (logger *Logger) sendTimeout(msg LogMsg, d time.Duration) bool {
+	logger.closeMu.RLock()
+	defer logger.closeMu.RUnlock()
+	if logger.closed {
+		return false
+	}
+	if taken, kept := logger.pauseTake(msg); taken {
+		return kept
+	}
+	if logger.synchronous {
+		logger.syncMu.Lock()
+		logger.printMessage(msg)
+		logger.syncMu.Unlock()
+		return true
+	}
+	if logger.syncFor(msg.Level) {
+		logger.writeInline(msg)
+		return true
+	}
+	queue := logger.output
+	if logger.adaptive != nil {
+		queue = logger.adaptive.intake
+	}
+	select {
+	case queue <- msg:
+		return true
+	default:
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case queue <- msg:
+		return true
+	case <-timer.C:
+		logger.drop()
+		return false
+	}
+}
@@ -0,0 +1,38 @@
+package liblog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogWithTimeout(t *testing.T) {
+	defer quiet()()
+	logger := Init("timeout")
+	logger.SetSilentDrop(true)
+	release := make(chan struct{})
+	var buf bytes.Buffer
+	logger.AddWriter(blockingWriter{release})
+	logger.AddWriter(&buf)
+
+	if !logger.LogWithTimeout(time.Second, InfoLevel, "taken by the worker") {
+		t.Errorf("first message not queued")
+	}
+	start := time.Now()
+	if logger.LogWithTimeout(10*time.Millisecond, InfoLevel, "worker busy") {
+		t.Errorf("message queued while the worker is blocked")
+	}
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond || elapsed > time.Second {
+		t.Errorf("waited %s", elapsed)
+	}
+	close(release)
+	logger.StopSync()
+
+	if logger.Dropped() != 1 || !strings.Contains(buf.String(), `"src_file":"timeout_test.go","src_line":19`) {
+		t.Errorf("dropped %d, output %s", logger.Dropped(), buf.String())
+	}
+	if logger.LogWithTimeout(time.Second, InfoLevel, "stopped") {
+		t.Errorf("message queued after stop")
+	}
+}
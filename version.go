@@ -0,0 +1,24 @@
+package liblog
+
+import "sync/atomic"
+
+// Version is the version of liblog, bumped with every release: the next
+// one while CHANGELOG.md has unreleased changes.
+const Version = "0.13.0"
+
+// EnableVersionField adds a "liblog_version" field holding Version to
+// every record, to tell which release produced it when the format changes
+// during a rollout. It is off by default.
+func (logger *Logger) EnableVersionField(enable bool) {
+	var v int32
+	if enable {
+		v = 1
+	}
+	atomic.StoreInt32(&logger.versionField, v)
+}
+
+func (logger *Logger) addVersion(msg *LogMsg) {
+	if atomic.LoadInt32(&logger.versionField) != 0 {
+		msg.Fields = append(msg.Fields[:len(msg.Fields):len(msg.Fields)], Field{"liblog_version", Version})
+	}
+}
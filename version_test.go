@@ -0,0 +1,72 @@
+package liblog
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestVersionField(t *testing.T) {
+	defer quiet()()
+	logger := Init("version")
+	var buf bytes.Buffer
+	logger.AddWriter(&buf)
+
+	logger.Info("without")
+	logger.EnableVersionField(true)
+	logger.InfoKV("with", "k", "v")
+	logger.StopSync()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if strings.Contains(lines[0], "liblog_version") {
+		t.Errorf("version written by default: %s", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], `"k":"v","liblog_version":"`+Version+`"}`) {
+		t.Errorf("version missing: %s", lines[1])
+	}
+}
+
+// TestVersionChangelog keeps Version in step with CHANGELOG.md: equal to
+// the latest release, or above it while there are unreleased changes.
+func TestVersionChangelog(t *testing.T) {
+	f, err := os.Open("CHANGELOG.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	unreleased := false
+	var release string
+	for scanner := bufio.NewScanner(f); scanner.Scan() && release == ""; {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "## [Unreleased]"):
+			unreleased = true
+		case strings.HasPrefix(line, "## [v"):
+			release = line[len("## [v"):strings.Index(line, "]")]
+		}
+	}
+	if release == "" {
+		t.Fatal("no release in CHANGELOG.md")
+	}
+	switch cmp := compareVersions(Version, release); {
+	case unreleased && cmp <= 0:
+		t.Errorf("Version %s is not above the latest release %s with unreleased changes", Version, release)
+	case !unreleased && cmp != 0:
+		t.Errorf("Version %s is not the latest release %s", Version, release)
+	}
+}
+
+func compareVersions(a, b string) int {
+	var v, w [3]int
+	fmt.Sscanf(a, "%d.%d.%d", &v[0], &v[1], &v[2])
+	fmt.Sscanf(b, "%d.%d.%d", &w[0], &w[1], &w[2])
+	for i := range v {
+		if v[i] != w[i] {
+			return v[i] - w[i]
+		}
+	}
+	return 0
+}
@@ -0,0 +1,115 @@
+package liblog
+
+import (
+	"io"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// WriteFailurePolicy tells what the logger does when a writer fails to
+// write a whole record.
+type WriteFailurePolicy int32
+
+const (
+	// IgnoreWriteFailures drops the rest of the record, the default.
+	IgnoreWriteFailures WriteFailurePolicy = iota
+	// RetryWriteFailures writes the rest of the record again, up to
+	// maxWriteRetries times.
+	RetryWriteFailures
+	// SkipBrokenWriters stops writing to a writer after a failure until
+	// ResetWriter is called, so that no record is written after a partial
+	// one. Writers of a type that cannot be compared are never skipped.
+	SkipBrokenWriters
+)
+
+const maxWriteRetries = 3
+
+// brokenWriters holds the writers skipped under SkipBrokenWriters.
+type brokenWriters struct {
+	mu      sync.Mutex
+	writers map[io.Writer]bool
+}
+
+func (logger *Logger) SetWriteFailurePolicy(policy WriteFailurePolicy) {
+	atomic.StoreInt32(&logger.writePolicy, int32(policy))
+}
+
+// ResetWriter makes the logger write to w again after it was skipped for
+// failing under SkipBrokenWriters.
+func (logger *Logger) ResetWriter(w io.Writer) {
+	if !hashable(w) {
+		return
+	}
+	logger.broken.mu.Lock()
+	delete(logger.broken.writers, w)
+	logger.broken.mu.Unlock()
+}
+
+// SetFailsafeStderr controls the last resort for records no writer could
+// take: when enabled, the default, a record that failed on stdout (or the
+// LOGOUTPUT file) and on every writer, or on every writer of its route,
+// is written to standard error: os.Stderr as of Init, or the writer set
+// with WithErrorOutput. A stdout set to ioutil.Discard counts as
+// failing. A single failing writer among several that succeed does not
+// trigger it, nor do writers skipped under SkipBrokenWriters as long as
+// another one works.
+func (logger *Logger) SetFailsafeStderr(enable bool) {
+	var v int32
+	if !enable {
+		v = 1
+	}
+	atomic.StoreInt32(&logger.noFailsafe, v)
+}
+
+func (logger *Logger) failsafe(data []byte) {
+	if atomic.LoadInt32(&logger.noFailsafe) == 0 {
+		writeSafe(logger.stderrWriter(), data)
+	}
+}
+
+// write writes a record to w, handling failures according to the policy,
+// and reports whether all of it was written.
+func (logger *Logger) write(w io.Writer, data []byte) bool {
+	policy := WriteFailurePolicy(atomic.LoadInt32(&logger.writePolicy))
+	skip := policy == SkipBrokenWriters && hashable(w)
+	if skip && logger.isBroken(w) {
+		return false
+	}
+	n, err := writeSafe(w, data)
+	for attempt := 0; policy == RetryWriteFailures && n < len(data) && attempt < maxWriteRetries; attempt++ {
+		var m int
+		m, err = writeSafe(w, data[n:])
+		n += m
+	}
+	if n >= len(data) {
+		return true
+	}
+	if err == nil {
+		err = io.ErrShortWrite
+	}
+	switch {
+	case skip:
+		logger.broken.mu.Lock()
+		if logger.broken.writers == nil {
+			logger.broken.writers = make(map[io.Writer]bool)
+		}
+		logger.broken.writers[w] = true
+		logger.broken.mu.Unlock()
+		internalf("writer %T failed, skipping it until reset: %v", w, err)
+	case policy == RetryWriteFailures:
+		internalf("writer %T failed: %v", w, err)
+	}
+	return false
+}
+
+func (logger *Logger) isBroken(w io.Writer) bool {
+	logger.broken.mu.Lock()
+	defer logger.broken.mu.Unlock()
+	return logger.broken.writers[w]
+}
+
+// hashable tells whether w can be used as a map key.
+func hashable(w io.Writer) bool {
+	return w != nil && reflect.TypeOf(w).Comparable()
+}
@@ -0,0 +1,104 @@
+package liblog
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+// shortWriter fails its first write after writing 10 bytes.
+type shortWriter struct {
+	buf    bytes.Buffer
+	failed bool
+}
+
+func (w *shortWriter) Write(p []byte) (int, error) {
+	if !w.failed && len(p) > 10 {
+		w.failed = true
+		n, _ := w.buf.Write(p[:10])
+		return n, errors.New("disk full")
+	}
+	return w.buf.Write(p)
+}
+
+func TestWriteFailurePolicy(t *testing.T) {
+	defer quiet()()
+	for _, policy := range []WriteFailurePolicy{IgnoreWriteFailures, RetryWriteFailures, SkipBrokenWriters} {
+		logger := Init("policy", WithSynchronous())
+		logger.SetWriteFailurePolicy(policy)
+		w := new(shortWriter)
+		logger.AddWriter(w)
+		logger.Info("first")
+		logger.Info("second")
+		logger.ResetWriter(w)
+		logger.Info("third")
+		logger.StopSync()
+
+		lines := strings.SplitAfter(w.buf.String(), "\n")
+		switch policy {
+		case IgnoreWriteFailures:
+			if len(lines) != 3 || !strings.HasPrefix(lines[0], `{"timestam{"timestamp"`) {
+				t.Errorf("ignore: %q", w.buf.String())
+			}
+		case RetryWriteFailures:
+			if len(lines) != 4 || !strings.Contains(lines[0], `"message":"first"`) {
+				t.Errorf("retry: %q", w.buf.String())
+			}
+		case SkipBrokenWriters:
+			if len(lines) != 2 || !strings.HasPrefix(lines[0], `{"timestam{"timestamp"`) || !strings.Contains(lines[0], `"message":"third"`) {
+				t.Errorf("skip: %q", w.buf.String())
+			}
+		}
+	}
+}
+
+func TestFailsafeStderr(t *testing.T) {
+	defer quiet()()
+	stderr, err := ioutil.TempFile("", "stderr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(stderr.Name())
+	defer stderr.Close()
+	os.Stderr = stderr
+
+	broken := failingWriter{errors.New("disk full")}
+	logger := Init("failsafe", WithSynchronous(), WithOutput(broken))
+	logger.AddWriter(broken)
+	logger.Info("lost everywhere")
+	logger.SetFailsafeStderr(false)
+	logger.Info("dropped")
+	logger.SetFailsafeStderr(true)
+	logger.AddWriter(&bytes.Buffer{})
+	logger.Info("reached one writer")
+	logger.StopSync()
+
+	out, _ := ioutil.ReadFile(stderr.Name())
+	if !strings.Contains(string(out), `"message":"lost everywhere"`) || strings.Count(string(out), "\n") != 1 {
+		t.Errorf("stderr: %s", out)
+	}
+}
+
+func TestFailsafeDiscardedStdout(t *testing.T) {
+	defer quiet()()
+	var stderr bytes.Buffer
+	logger := Init("failsafe", WithSynchronous(), WithOutput(ioutil.Discard), WithErrorOutput(&stderr))
+	logger.AddWriter(failingWriter{errors.New("disk full")})
+	logger.Error("lost")
+	logger.StopSync()
+
+	if !strings.Contains(stderr.String(), `"message":"lost"`) {
+		t.Errorf("stderr: %q", stderr.String())
+	}
+
+	stderr.Reset()
+	silent := Init("failsafe", WithSynchronous(), WithOutput(ioutil.Discard), WithErrorOutput(&stderr))
+	silent.Error("discarded on purpose")
+	silent.StopSync()
+	if stderr.Len() != 0 {
+		t.Errorf("logger without writers fell back to stderr: %q", stderr.String())
+	}
+}